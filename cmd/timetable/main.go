@@ -0,0 +1,62 @@
+// Command timetable solves an exam timetabling problem offline, without a database.
+//
+// It reads a problem as JSON (see seating.TimetableProblem) and writes the resulting
+// timetable as JSON:
+//
+//	timetable -in problem.json -out timetable.json
+//
+// Input defaults to stdin and output to stdout.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"ExamSeatPlanner/internal/seating"
+)
+
+func main() {
+	in := flag.String("in", "", "problem JSON file (default stdin)")
+	out := flag.String("out", "", "timetable JSON file (default stdout)")
+	flag.Parse()
+
+	var reader io.Reader = os.Stdin
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			log.Fatalf("[timetable] Could not open problem: %v", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+	var problem seating.TimetableProblem
+	if err := json.NewDecoder(reader).Decode(&problem); err != nil {
+		log.Fatalf("[timetable] Could not read problem: %v", err)
+	}
+
+	timetable, err := seating.SolveTimetable(problem)
+	if err != nil {
+		log.Fatalf("[timetable] %v", err)
+	}
+
+	var writer io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("[timetable] Could not create output: %v", err)
+		}
+		defer file.Close()
+		writer = file
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(timetable); err != nil {
+		log.Fatalf("[timetable] Could not write timetable: %v", err)
+	}
+	if len(timetable.Unscheduled) > 0 {
+		log.Printf("[timetable] %d exams could not be scheduled", len(timetable.Unscheduled))
+	}
+}
@@ -0,0 +1,199 @@
+package seating
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Accommodations a student may need, set per student in a StudentList.
+const (
+	AccommodationGroundFloor  = "ground_floor"  // Room must be on the ground floor
+	AccommodationWheelchair   = "wheelchair"    // Seat must be a wheelchair-accessible cell
+	AccommodationFrontSeat    = "front_seat"    // Seat must be in one of the front rows
+	AccommodationSeparateRoom = "separate_room" // Sits apart in a separate room, e.g. with extra time
+)
+
+// frontRows is how many rows, counted from the front, hold front seats.
+const frontRows = 2
+
+// ErrAccommodationUnmet is wrapped when generation cannot give every student with
+// accommodations a matching seat.
+var ErrAccommodationUnmet = errors.New("accommodations cannot be met")
+
+// IsValidAccommodation reports whether name is a known accommodation.
+func IsValidAccommodation(name string) bool {
+	switch name {
+	case AccommodationGroundFloor, AccommodationWheelchair, AccommodationFrontSeat, AccommodationSeparateRoom:
+		return true
+	}
+	return false
+}
+
+// ValidateAccommodations checks that every student only lists known accommodations.
+func ValidateAccommodations(students []Student) error {
+	for _, st := range students {
+		for _, name := range st.Accommodations {
+			if !IsValidAccommodation(name) {
+				return fmt.Errorf("student %s has unknown accommodation %q", st.StudentID, name)
+			}
+		}
+	}
+	return nil
+}
+
+// needs reports whether a student has the given accommodation.
+func (st StudentWithGroup) needs(accommodation string) bool {
+	for _, name := range st.Accommodations {
+		if name == accommodation {
+			return true
+		}
+	}
+	return false
+}
+
+// accommodationRank orders students so that the hardest to satisfy are placed first.
+func accommodationRank(st StudentWithGroup) int {
+	rank := 0
+	if st.needs(AccommodationWheelchair) {
+		rank += 8
+	}
+	if st.needs(AccommodationSeparateRoom) {
+		rank += 4
+	}
+	if st.needs(AccommodationGroundFloor) {
+		rank += 2
+	}
+	if st.needs(AccommodationFrontSeat) {
+		rank++
+	}
+	return rank
+}
+
+// roomSuits reports whether a student with accommodations may sit in the room.
+// Separate rooms take only students who need one, apart from the room's own lists.
+func roomSuits(room *Room, st StudentWithGroup, home bool) bool {
+	if st.needs(AccommodationGroundFloor) && !room.GroundFloor {
+		return false
+	}
+	if st.needs(AccommodationSeparateRoom) {
+		return room.SeparateRoom
+	}
+	return home || !room.SeparateRoom
+}
+
+// cellSuits reports whether a cell of the given type and 1-based row fits the student.
+func cellSuits(cellType string, row int, st StudentWithGroup) bool {
+	if !isSeatable(cellType) {
+		return false
+	}
+	if st.needs(AccommodationWheelchair) && cellType != CellAccessible {
+		return false
+	}
+	return !st.needs(AccommodationFrontSeat) || row <= frontRows
+}
+
+// splitAccommodated moves the students with accommodations out of students into
+// accommodated, recording home as the index of the room whose lists name them
+// (-1 when pooled). It returns the remaining students.
+func splitAccommodated(students []StudentWithGroup, home int, accommodated *[]StudentWithGroup, homes *[]int) []StudentWithGroup {
+	if students == nil {
+		return nil
+	}
+	kept := students[:0:0]
+	for _, st := range students {
+		if len(st.Accommodations) == 0 {
+			kept = append(kept, st)
+			continue
+		}
+		*accommodated = append(*accommodated, st)
+		*homes = append(*homes, home)
+	}
+	return kept
+}
+
+// placeAccommodations reserves a matching seat for every student with accommodations
+// before anyone else is seated, keeping clear of locked cells. A student stays in their
+// home room when it has a matching seat; within a room, seats next to the same group
+// and, for students who do not need one, accessible seats are avoided. It returns the
+// reserved seats per room, or ErrAccommodationUnmet naming every student left without.
+func placeAccommodations(rooms []*Room, locks [][]LockedSeat, lockedStudents map[string]StudentWithGroup, students []StudentWithGroup, homes []int) ([][]LockedSeat, error) {
+	reserved := make([][]LockedSeat, len(rooms))
+	if len(students) == 0 {
+		return reserved, nil
+	}
+	types := make([][]string, len(rooms))
+	taken := make([]map[[2]int]string, len(rooms)) // Group of the student on each taken cell
+	for i, room := range rooms {
+		types[i] = room.cellTypes()
+		taken[i] = make(map[[2]int]string)
+		for _, lock := range locks[i] {
+			taken[i][[2]int{lock.Row, lock.Column}] = lockedStudents[lock.StudentID].Group
+		}
+	}
+
+	order := make([]int, len(students))
+	for k := range order {
+		order[k] = k
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return accommodationRank(students[order[a]]) > accommodationRank(students[order[b]])
+	})
+
+	var unmet []string
+	for _, k := range order {
+		st := students[k]
+		bestRoom, bestCost := -1, -1
+		var bestCell [2]int
+		search := func(homeOnly bool) {
+			for i, room := range rooms {
+				home := homes[k] == i
+				if (homeOnly && !home) || !roomSuits(room, st, home) {
+					continue
+				}
+				for idx, cellType := range types[i] {
+					cell := [2]int{idx/room.Columns + 1, idx%room.Columns + 1}
+					if _, ok := taken[i][cell]; ok || !cellSuits(cellType, cell[0], st) {
+						continue
+					}
+					cost := 0
+					for _, off := range neighbourOffsets {
+						if group, ok := taken[i][[2]int{cell[0] + off[0], cell[1] + off[1]}]; ok && groupsConflict(group, st.Group) {
+							cost += 2
+						}
+					}
+					if cellType == CellAccessible && !st.needs(AccommodationWheelchair) {
+						cost++
+					}
+					if bestCost < 0 || cost < bestCost {
+						bestRoom, bestCell, bestCost = i, cell, cost
+					}
+				}
+			}
+		}
+		if homes[k] >= 0 {
+			search(true)
+		}
+		if bestRoom < 0 {
+			search(false)
+		}
+		if bestRoom < 0 {
+			unmet = append(unmet, fmt.Sprintf("%s (%s)", st.StudentID, strings.Join(st.Accommodations, ", ")))
+			continue
+		}
+		taken[bestRoom][bestCell] = st.Group
+		reserved[bestRoom] = append(reserved[bestRoom], LockedSeat{
+			RoomID:    rooms[bestRoom].ID,
+			Row:       bestCell[0],
+			Column:    bestCell[1],
+			StudentID: st.StudentID,
+		})
+	}
+	if len(unmet) > 0 {
+		return nil, fmt.Errorf("%w: no matching seat left for %s", ErrAccommodationUnmet, strings.Join(unmet, "; "))
+	}
+	return reserved, nil
+}
+
+// Why: Students with access needs must never depend on where an algorithm happens to put them; reserving their seats first, and refusing to generate otherwise, makes the guarantee explicit.
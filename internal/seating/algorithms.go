@@ -0,0 +1,275 @@
+package seating
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// AlgorithmParameter describes a tunable option accepted by a seating algorithm.
+type AlgorithmParameter struct {
+	Name        string      `json:"name"`              // Parameter key as sent by clients
+	Type        string      `json:"type"`              // Value type (int, float, bool, string)
+	Default     interface{} `json:"default,omitempty"` // Value used when the option is omitted
+	Description string      `json:"description"`       // Human readable explanation for the frontend
+}
+
+// AlgorithmParams carries caller-supplied values for an algorithm's tunable parameters.
+type AlgorithmParams map[string]interface{}
+
+// Int returns the named parameter as an int, or def when it is missing or not numeric.
+// JSON numbers decode as float64, so both representations are accepted.
+func (p AlgorithmParams) Int(name string, def int) int {
+	switch v := p[name].(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return def
+}
+
+// ArrangeOptions carries the per-run inputs handed to an algorithm.
+type ArrangeOptions struct {
+	Params AlgorithmParams // Caller-supplied tunables
+	Rand   *rand.Rand      // Seeded source for any randomness, so runs are reproducible
+}
+
+// SeatingAlgorithm arranges a group of students inside a single room. Algorithms
+// separate students by StudentWithGroup.Group, which the service sets from the
+// requested grouping key.
+type SeatingAlgorithm interface {
+	Name() string
+	Description() string
+	Parameters() []AlgorithmParameter
+	Arrange(room *Room, students []StudentWithGroup, opts ArrangeOptions) ([]Seat, error)
+}
+
+// AlgorithmInfo is the JSON view of a registered algorithm.
+type AlgorithmInfo struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Parameters  []AlgorithmParameter `json:"parameters"`
+}
+
+// AlgorithmRegistry keeps the seating algorithms available to the service, in registration order.
+type AlgorithmRegistry struct {
+	mu         sync.RWMutex
+	algorithms map[string]SeatingAlgorithm
+	order      []string
+}
+
+// NewAlgorithmRegistry creates a registry pre-populated with the built-in algorithms.
+func NewAlgorithmRegistry() *AlgorithmRegistry {
+	r := &AlgorithmRegistry{algorithms: make(map[string]SeatingAlgorithm)}
+	r.MustRegister(parallelAlgorithm{})
+	r.MustRegister(simpleAlgorithm{})
+	r.MustRegister(separatedAlgorithm{})
+	return r
+}
+
+// Register adds an algorithm to the registry. Names must be unique.
+func (r *AlgorithmRegistry) Register(algorithm SeatingAlgorithm) error {
+	name := algorithm.Name()
+	if name == "" {
+		return errors.New("algorithm name is required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.algorithms[name]; exists {
+		return fmt.Errorf("algorithm %q is already registered", name)
+	}
+	r.algorithms[name] = algorithm
+	r.order = append(r.order, name)
+	return nil
+}
+
+// MustRegister is like Register but panics on error; intended for built-in algorithms.
+func (r *AlgorithmRegistry) MustRegister(algorithm SeatingAlgorithm) {
+	if err := r.Register(algorithm); err != nil {
+		panic(err)
+	}
+}
+
+// Get looks up an algorithm by name.
+func (r *AlgorithmRegistry) Get(name string) (SeatingAlgorithm, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	algorithm, ok := r.algorithms[name]
+	return algorithm, ok
+}
+
+// List returns a description of every registered algorithm.
+func (r *AlgorithmRegistry) List() []AlgorithmInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	infos := make([]AlgorithmInfo, 0, len(r.order))
+	for _, name := range r.order {
+		algorithm := r.algorithms[name]
+		params := algorithm.Parameters()
+		if params == nil {
+			params = []AlgorithmParameter{}
+		}
+		infos = append(infos, AlgorithmInfo{
+			Name:        algorithm.Name(),
+			Description: algorithm.Description(),
+			Parameters:  params,
+		})
+	}
+	return infos
+}
+
+// parallelAlgorithm seats one group per column.
+type parallelAlgorithm struct{}
+
+func (parallelAlgorithm) Name() string { return "parallel" }
+
+func (parallelAlgorithm) Description() string {
+	return "Fills each column with students of a single group (department by default), cycling groups across columns."
+}
+
+func (parallelAlgorithm) Parameters() []AlgorithmParameter { return nil }
+
+func (parallelAlgorithm) Arrange(room *Room, students []StudentWithGroup, _ ArrangeOptions) ([]Seat, error) {
+	return generateParallelSeating(room, students), nil
+}
+
+// simpleAlgorithm seats students in serpentine order, interleaving groups.
+type simpleAlgorithm struct{}
+
+func (simpleAlgorithm) Name() string { return "simple" }
+
+func (simpleAlgorithm) Description() string {
+	return "Seats students row by row in serpentine order, alternating groups round-robin without adjacency checks."
+}
+
+func (simpleAlgorithm) Parameters() []AlgorithmParameter { return nil }
+
+func (simpleAlgorithm) Arrange(room *Room, students []StudentWithGroup, _ ArrangeOptions) ([]Seat, error) {
+	return generateRandomSeating(room, students), nil
+}
+
+// separatedAlgorithm uses the constraint solver to keep groups apart.
+type separatedAlgorithm struct{}
+
+func (separatedAlgorithm) Name() string { return "separated" }
+
+func (separatedAlgorithm) Description() string {
+	return "Constraint solver that keeps students of the same group out of all 8 neighbouring seats, minimizing violations when a perfect arrangement is impossible."
+}
+
+func (separatedAlgorithm) Parameters() []AlgorithmParameter {
+	return []AlgorithmParameter{
+		{Name: "max_restarts", Type: "int", Default: 20, Description: "Number of randomized search restarts before falling back to violation minimization"},
+		{Name: "max_steps", Type: "int", Default: 20000, Description: "Backtracking steps allowed per restart"},
+		{Name: "local_search_iterations", Type: "int", Default: 20000, Description: "Swap attempts per restart when minimizing violations"},
+	}
+}
+
+func (separatedAlgorithm) Arrange(room *Room, students []StudentWithGroup, opts ArrangeOptions) ([]Seat, error) {
+	return solveSeating(room, students, opts)
+}
+
+// generateParallelSeating arranges students by group per column, skipping unseatable cells.
+func generateParallelSeating(room *Room, students []StudentWithGroup) []Seat {
+	seats := emptySeats(room)
+	seatable := room.seatableMask()
+	// Group students by their separation group
+	deptMap := map[string][]StudentWithGroup{}
+	var depts []string
+	for _, student := range students {
+		key := groupBucket(student.Group)
+		if _, ok := deptMap[key]; !ok {
+			depts = append(depts, key)
+		}
+		deptMap[key] = append(deptMap[key], student)
+	}
+	// Assign each group to a column (cycle if more columns than groups)
+	colDept := make([]string, room.Columns)
+	for i := 0; i < room.Columns; i++ {
+		colDept[i] = depts[i%len(depts)]
+	}
+	// For each column, fill with students from the assigned group
+	colStudentIdx := make(map[string]int)
+	for j := 0; j < room.Columns; j++ {
+		dept := colDept[j]
+		for i := 0; i < room.Rows; i++ {
+			seatIndex := i*room.Columns + j
+			if !seatable[seatIndex] {
+				continue
+			}
+			idx := colStudentIdx[dept]
+			if idx < len(deptMap[dept]) {
+				seats[seatIndex].StudentID = deptMap[dept][idx].StudentID
+				seats[seatIndex].IsEmpty = false
+				colStudentIdx[dept]++
+			}
+		}
+	}
+	// Groups larger than their columns spill into any seat still free, column by column
+	var leftover []StudentWithGroup
+	for _, dept := range depts {
+		leftover = append(leftover, deptMap[dept][colStudentIdx[dept]:]...)
+	}
+	for j := 0; j < room.Columns && len(leftover) > 0; j++ {
+		for i := 0; i < room.Rows && len(leftover) > 0; i++ {
+			seatIndex := i*room.Columns + j
+			if seatable[seatIndex] && seats[seatIndex].IsEmpty {
+				seats[seatIndex].StudentID = leftover[0].StudentID
+				seats[seatIndex].IsEmpty = false
+				leftover = leftover[1:]
+			}
+		}
+	}
+	return seats
+}
+
+// generateRandomSeating arranges students in a classic snake/serpentine (row-wise, alternating direction) order, interleaving groups in round-robin order, with no adjacency constraints. Unseatable cells are skipped.
+func generateRandomSeating(room *Room, students []StudentWithGroup) []Seat {
+	seats := emptySeats(room)
+	seatable := room.seatableMask()
+	// Group students by their separation group
+	deptMap := map[string][]StudentWithGroup{}
+	var depts []string
+	for _, s := range students {
+		key := groupBucket(s.Group)
+		if _, ok := deptMap[key]; !ok {
+			depts = append(depts, key)
+		}
+		deptMap[key] = append(deptMap[key], s)
+	}
+	remaining := len(students)
+	deptIdx := 0
+	for i := 0; i < room.Rows && remaining > 0; i++ {
+		for k := 0; k < room.Columns && remaining > 0; k++ {
+			j := k
+			if i%2 == 1 { // Odd row: right-to-left
+				j = room.Columns - 1 - k
+			}
+			seatIdx := i*room.Columns + j
+			if !seatable[seatIdx] {
+				continue
+			}
+			// Find next group with students left
+			for tries := 0; tries < len(depts); tries++ {
+				dept := depts[deptIdx%len(depts)]
+				deptIdx++
+				if len(deptMap[dept]) > 0 {
+					seats[seatIdx].StudentID = deptMap[dept][0].StudentID
+					seats[seatIdx].IsEmpty = false
+					deptMap[dept] = deptMap[dept][1:]
+					remaining--
+					break
+				}
+			}
+		}
+	}
+	return seats
+}
+
+// Why: Keeping algorithms behind one interface lets the service and the frontend discover them from a single registry instead of duplicating name checks.
@@ -0,0 +1,366 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotEnoughRooms is wrapped when the free rooms cannot hold an exam's students.
+var ErrNotEnoughRooms = errors.New("not enough free rooms")
+
+// AllocationOptions configures automatic room selection for an exam.
+type AllocationOptions struct {
+	StudentListIDs []primitive.ObjectID // Lists sitting the exam
+	Spacing        string               // Spacing rule the rooms must hold the students under
+	Building       string               // Preferred building, used whenever it has enough room alone
+	Commit         bool                 // Create the exam rooms; otherwise only preview them
+}
+
+// AllocatedRoom is a room chosen for an exam together with the lists it would seat.
+type AllocatedRoom struct {
+	RoomID         primitive.ObjectID   `json:"room_id"`
+	Name           string               `json:"name"`
+	Building       string               `json:"building"`
+	Capacity       int                  `json:"capacity"` // Seats left under the spacing rule
+	StudentListIDs []primitive.ObjectID `json:"student_list_ids"`
+	Students       int                  `json:"students"`                // Students of its lists who sit in this room
+	SeparateRoom   bool                 `json:"separate_room,omitempty"` // Holds the students who need a separate room
+}
+
+// RoomAllocation is the outcome of automatic room selection, previewed or committed.
+type RoomAllocation struct {
+	ExamID    primitive.ObjectID `json:"exam_id"`
+	Rooms     []AllocatedRoom    `json:"rooms"`
+	Students  int                `json:"students"`
+	Capacity  int                `json:"capacity"`
+	Mode      string             `json:"mode"` // Generation mode the lists were packed for
+	Warnings  []string           `json:"warnings"`
+	Committed bool               `json:"committed"`
+	ExamRooms []*ExamRoom        `json:"exam_rooms,omitempty"` // Created assignments, once committed
+	Clashes   []StudentClash     `json:"clashes,omitempty"`    // Students of the exam listed for overlapping exams, once committed
+}
+
+// freeRooms returns the rooms with seats left under the spacing rule that are neither
+// blocked nor booked by another exam at the exam's time.
+func (s *SeatingService) freeRooms(ctx context.Context, exam *Exam, spacing string) ([]*Room, error) {
+	rooms, err := s.repo.GetAllRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roomIDs := make([]primitive.ObjectID, len(rooms))
+	for i, room := range rooms {
+		roomIDs[i] = room.ID
+	}
+	bookings, err := s.roomBookings(ctx, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+	free := make([]*Room, 0, len(rooms))
+	for _, room := range rooms {
+		if bookingConflict(bookings[room.ID], exam, false) != nil {
+			continue
+		}
+		if room = applySpacing(room, spacing); room.Capacity > 0 {
+			free = append(free, room)
+		}
+	}
+	return free, nil
+}
+
+// capacityOf returns the total capacity of rooms.
+func capacityOf(rooms []*Room) int {
+	total := 0
+	for _, room := range rooms {
+		total += room.Capacity
+	}
+	return total
+}
+
+// pickRooms returns the fewest rooms that hold need students: the largest ones, with the
+// last swapped for the smallest room that still suffices. It returns nil when even all
+// rooms together are too small.
+func pickRooms(rooms []*Room, need int) []*Room {
+	sorted := append([]*Room(nil), rooms...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Capacity != sorted[j].Capacity {
+			return sorted[i].Capacity > sorted[j].Capacity
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	total, taken := 0, 0
+	for taken < len(sorted) && total < need {
+		total += sorted[taken].Capacity
+		taken++
+	}
+	if total < need || taken == 0 {
+		return nil
+	}
+	picked := sorted[:taken:taken]
+	rest := total - picked[taken-1].Capacity
+	for i := len(sorted) - 1; i >= taken-1; i-- {
+		if rest+sorted[i].Capacity >= need {
+			picked[taken-1] = sorted[i]
+			break
+		}
+	}
+	return picked
+}
+
+// chooseRooms picks the rooms for need students, keeping them in one building when any
+// can hold them all: the preferred building first, otherwise the one needing the fewest
+// rooms and leaving the fewest seats empty. When no building suffices alone, the roomiest
+// buildings are filled first.
+func chooseRooms(rooms []*Room, need int, preferred string) []*Room {
+	if need <= 0 {
+		return nil
+	}
+	byBuilding := make(map[string][]*Room)
+	var buildings []string
+	for _, room := range rooms {
+		if _, ok := byBuilding[room.Building]; !ok {
+			buildings = append(buildings, room.Building)
+		}
+		byBuilding[room.Building] = append(byBuilding[room.Building], room)
+	}
+	sort.Strings(buildings)
+	if preferred != "" {
+		if picked := pickRooms(byBuilding[preferred], need); picked != nil {
+			return picked
+		}
+	}
+	var best []*Room
+	for _, building := range buildings {
+		picked := pickRooms(byBuilding[building], need)
+		if picked == nil {
+			continue
+		}
+		if best == nil || len(picked) < len(best) || (len(picked) == len(best) && capacityOf(picked) < capacityOf(best)) {
+			best = picked
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	sort.SliceStable(buildings, func(i, j int) bool {
+		return capacityOf(byBuilding[buildings[i]]) > capacityOf(byBuilding[buildings[j]])
+	})
+	var picked []*Room
+	remaining := need
+	for _, building := range buildings {
+		if part := pickRooms(byBuilding[building], remaining); part != nil {
+			return append(picked, part...)
+		}
+		picked = append(picked, byBuilding[building]...)
+		remaining -= capacityOf(byBuilding[building])
+	}
+	return nil
+}
+
+// packLists puts every list whole into one room, largest lists first into the fullest
+// room that still fits them, so the exam can be generated per room. It reports false,
+// leaving rooms untouched, when some list fits nowhere.
+func packLists(rooms []AllocatedRoom, lists []*StudentList, sizes map[primitive.ObjectID]int) bool {
+	order := append([]*StudentList(nil), lists...)
+	sort.SliceStable(order, func(i, j int) bool { return sizes[order[i].ID] > sizes[order[j].ID] })
+	used := make([]int, len(rooms))
+	target := make(map[primitive.ObjectID]int, len(order))
+	for _, list := range order {
+		best := -1
+		for i, room := range rooms {
+			// Separate rooms only take lists whose students all sit apart anyway
+			if (room.SeparateRoom && sizes[list.ID] > 0) || used[i]+sizes[list.ID] > room.Capacity {
+				continue
+			}
+			if best < 0 || room.Capacity-used[i] < rooms[best].Capacity-used[best] {
+				best = i
+			}
+		}
+		if best < 0 {
+			return false
+		}
+		used[best] += sizes[list.ID]
+		target[list.ID] = best
+	}
+	for _, list := range lists {
+		i := target[list.ID]
+		rooms[i].StudentListIDs = append(rooms[i].StudentListIDs, list.ID)
+		rooms[i].Students += sizes[list.ID]
+	}
+	return true
+}
+
+// spreadLists hands each list to the room with the most free seats. Rooms may end up
+// over capacity on paper; pooled generation then spreads the students over all rooms.
+func spreadLists(rooms []AllocatedRoom, lists []*StudentList, sizes map[primitive.ObjectID]int) {
+	for _, list := range lists {
+		best := -1
+		for i, room := range rooms {
+			if room.SeparateRoom {
+				continue
+			}
+			if best < 0 || room.Capacity-room.Students > rooms[best].Capacity-rooms[best].Students {
+				best = i
+			}
+		}
+		rooms[best].StudentListIDs = append(rooms[best].StudentListIDs, list.ID)
+		rooms[best].Students += sizes[list.ID]
+	}
+}
+
+// AllocateExamRooms chooses the fewest free rooms that hold the students of the given
+// lists under the spacing rule, preferring a single building, and splits the lists over
+// them. Students who need a separate room get the smallest free separate room that holds
+// them. Unless opts.Commit is set the result is only a preview; committing records an
+// ExamRoom per chosen room and requires the exam to have none yet.
+func (s *SeatingService) AllocateExamRooms(ctx context.Context, examID primitive.ObjectID, opts AllocationOptions) (*RoomAllocation, error) {
+	if !IsValidSpacing(opts.Spacing) {
+		return nil, fmt.Errorf("invalid spacing %q", opts.Spacing)
+	}
+	exam, err := s.repo.FindExamByID(ctx, examID)
+	if err != nil || exam == nil {
+		return nil, errors.New("exam not found")
+	}
+	if opts.Commit {
+		existing, err := s.repo.GetExamRooms(ctx, examID)
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 {
+			return nil, errors.New("exam already has rooms assigned")
+		}
+	}
+	lists, err := s.repo.FindStudentListsByIDs(ctx, opts.StudentListIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) != len(opts.StudentListIDs) {
+		return nil, errors.New("student list not found")
+	}
+	lists = orderListsByIDs(lists, opts.StudentListIDs)
+
+	// A student on several lists takes one seat, counted on the first list naming them
+	sizes := make(map[primitive.ObjectID]int, len(lists))
+	seen := make(map[string]bool)
+	var separate, groundFloor, wheelchair int
+	for _, list := range lists {
+		for _, st := range studentsFromLists([]*StudentList{list}) {
+			if seen[st.StudentID] {
+				continue
+			}
+			seen[st.StudentID] = true
+			if st.needs(AccommodationGroundFloor) {
+				groundFloor++
+			}
+			if st.needs(AccommodationWheelchair) {
+				wheelchair++
+			}
+			if st.needs(AccommodationSeparateRoom) {
+				separate++
+				continue
+			}
+			sizes[list.ID]++
+		}
+	}
+	need := len(seen) - separate
+
+	free, err := s.freeRooms(ctx, exam, opts.Spacing)
+	if err != nil {
+		return nil, err
+	}
+	var general, apart []*Room
+	for _, room := range free {
+		if room.SeparateRoom {
+			apart = append(apart, room)
+		} else {
+			general = append(general, room)
+		}
+	}
+	chosen := chooseRooms(general, need, opts.Building)
+	if chosen == nil && need > 0 {
+		return nil, fmt.Errorf("%w: %d students need seats but free rooms hold %d", ErrNotEnoughRooms, need, capacityOf(general))
+	}
+	separateRooms := chooseRooms(apart, separate, "")
+	if len(chosen) > 0 {
+		// Keep separate rooms in the building of the main rooms when possible
+		separateRooms = chooseRooms(apart, separate, chosen[0].Building)
+	}
+	if separateRooms == nil && separate > 0 {
+		return nil, fmt.Errorf("%w: %d students need a separate room but free separate rooms hold %d", ErrNotEnoughRooms, separate, capacityOf(apart))
+	}
+
+	allocation := &RoomAllocation{
+		ExamID:   examID,
+		Rooms:    make([]AllocatedRoom, 0, len(chosen)+len(separateRooms)),
+		Students: len(seen),
+		Mode:     GenerationModePerRoom,
+		Warnings: []string{},
+	}
+	groundSeats, accessibleSeats := 0, 0
+	for _, room := range append(append([]*Room(nil), chosen...), separateRooms...) {
+		allocation.Rooms = append(allocation.Rooms, AllocatedRoom{
+			RoomID:         room.ID,
+			Name:           room.Name,
+			Building:       room.Building,
+			Capacity:       room.Capacity,
+			StudentListIDs: []primitive.ObjectID{},
+			SeparateRoom:   room.SeparateRoom,
+		})
+		allocation.Capacity += room.Capacity
+		if room.GroundFloor {
+			groundSeats += room.Capacity
+		}
+		for _, t := range room.cellTypes() {
+			if t == CellAccessible {
+				accessibleSeats++
+			}
+		}
+	}
+	remaining := separate
+	for i := range allocation.Rooms {
+		if allocation.Rooms[i].SeparateRoom {
+			allocation.Rooms[i].Students = min(remaining, allocation.Rooms[i].Capacity)
+			remaining -= allocation.Rooms[i].Students
+		}
+	}
+	if !packLists(allocation.Rooms, lists, sizes) {
+		allocation.Mode = GenerationModePooled
+		spreadLists(allocation.Rooms, lists, sizes)
+		allocation.Warnings = append(allocation.Warnings, "some student lists do not fit a single room whole; generate in pooled mode")
+	}
+	if groundFloor > groundSeats {
+		allocation.Warnings = append(allocation.Warnings, fmt.Sprintf("%d students need the ground floor but the chosen rooms have %d ground-floor seats", groundFloor, groundSeats))
+	}
+	if wheelchair > accessibleSeats {
+		allocation.Warnings = append(allocation.Warnings, fmt.Sprintf("%d students need a wheelchair-accessible seat but the chosen rooms have %d", wheelchair, accessibleSeats))
+	}
+
+	if !opts.Commit {
+		return allocation, nil
+	}
+	now := time.Now()
+	for _, room := range allocation.Rooms {
+		examRoom := &ExamRoom{
+			ID:             primitive.NewObjectID(),
+			ExamID:         examID,
+			RoomID:         room.RoomID,
+			StudentListIDs: room.StudentListIDs,
+			Invigilators:   []primitive.ObjectID{},
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := s.repo.CreateExamRoom(ctx, examRoom); err != nil {
+			return nil, err
+		}
+		allocation.ExamRooms = append(allocation.ExamRooms, examRoom)
+	}
+	allocation.Committed = true
+	return allocation, nil
+}
+
+// Why: Picking rooms by hand for every exam wastes halls and double-books them; choosing the fewest free rooms, kept in one building, and previewing before recording makes allocation routine.
@@ -0,0 +1,107 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvigilatorUnavailable is wrapped when an invigilator declared they cannot attend an exam.
+var ErrInvigilatorUnavailable = errors.New("invigilator is unavailable")
+
+// staffByEmail returns the user behind a staff email, as used by the self-service endpoints.
+func (s *SeatingService) staffByEmail(ctx context.Context, email string) (*User, error) {
+	user, err := s.repo.FindUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("invigilator not found")
+	}
+	return user, nil
+}
+
+// DeclareUnavailability records a period the member of staff with the given email
+// cannot invigilate.
+func (s *SeatingService) DeclareUnavailability(ctx context.Context, email string, entry *Unavailability) error {
+	if !entry.End.After(entry.Start) {
+		return errors.New("unavailability must end after it starts")
+	}
+	user, err := s.staffByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	entry.InvigilatorID = user.ID
+	entry.Email = user.Email
+	return s.repo.CreateUnavailability(ctx, entry)
+}
+
+// GetMyUnavailability lists the periods the member of staff declared, earliest first.
+func (s *SeatingService) GetMyUnavailability(ctx context.Context, email string) ([]*Unavailability, error) {
+	user, err := s.staffByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindUnavailability(ctx, &user.ID, nil, nil)
+}
+
+// DeleteMyUnavailability withdraws one of the member of staff's own declarations.
+func (s *SeatingService) DeleteMyUnavailability(ctx context.Context, email string, id primitive.ObjectID) error {
+	user, err := s.staffByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteUnavailability(ctx, id, user.ID)
+}
+
+// ListUnavailability returns the declarations overlapping the window, optionally for one
+// invigilator, for admins planning duties.
+func (s *SeatingService) ListUnavailability(ctx context.Context, invigilatorID *primitive.ObjectID, from, to *time.Time) ([]*Unavailability, error) {
+	return s.repo.FindUnavailability(ctx, invigilatorID, from, to)
+}
+
+// unavailableDuring maps every invigilator who declared unavailability overlapping any of
+// the exams to their first such declaration.
+func (s *SeatingService) unavailableDuring(ctx context.Context, exams []*Exam) (map[primitive.ObjectID]*Unavailability, error) {
+	unavailable := make(map[primitive.ObjectID]*Unavailability)
+	for _, exam := range exams {
+		start, end := exam.Date, examEnd(exam)
+		entries, err := s.repo.FindUnavailability(ctx, nil, &start, &end)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if _, ok := unavailable[entry.InvigilatorID]; !ok {
+				unavailable[entry.InvigilatorID] = entry
+			}
+		}
+	}
+	return unavailable, nil
+}
+
+// CheckInvigilatorAvailable returns ErrInvigilatorUnavailable, describing the declaration,
+// when the invigilator declared they cannot attend the exam.
+func (s *SeatingService) CheckInvigilatorAvailable(ctx context.Context, examID, invigilatorID primitive.ObjectID) error {
+	exam, err := s.repo.FindExamByID(ctx, examID)
+	if err != nil {
+		return err
+	}
+	if exam == nil {
+		return errors.New("exam not found")
+	}
+	unavailable, err := s.unavailableDuring(ctx, []*Exam{exam})
+	if err != nil {
+		return err
+	}
+	entry, ok := unavailable[invigilatorID]
+	if !ok {
+		return nil
+	}
+	const layout = "2006-01-02 15:04"
+	return fmt.Errorf("%w: %s declared unavailability from %s to %s (%s)", ErrInvigilatorUnavailable, entry.Email, entry.Start.Format(layout), entry.End.Format(layout), entry.Reason)
+}
+
+// Why: Leave was only known by word of mouth, so duties landed on absent staff; declarations checked at assignment time turn that into an explicit conflict.
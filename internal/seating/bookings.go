@@ -0,0 +1,249 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of entries in a room's calendar.
+const (
+	BookingExam  = "exam"  // The room is assigned to an exam
+	BookingBlock = "block" // The room was blocked by hand, e.g. for maintenance
+)
+
+// ErrRoomDoubleBooked is wrapped when a room would be used by two exams, or by an exam
+// while blocked, at the same time.
+var ErrRoomDoubleBooked = errors.New("room is already booked")
+
+// RoomBooking is one period during which a room is taken.
+type RoomBooking struct {
+	Kind      string              `json:"kind"` // BookingExam or BookingBlock
+	Start     time.Time           `json:"start"`
+	End       time.Time           `json:"end"`
+	ExamID    *primitive.ObjectID `json:"exam_id,omitempty"`
+	ExamTitle string              `json:"exam_title,omitempty"`
+	Shared    bool                `json:"shared,omitempty"` // Exam booking open to concurrent shared exams
+	Draft     bool                `json:"draft,omitempty"`  // Exam booking made by the timetable generator, not yet confirmed
+	BlockID   *primitive.ObjectID `json:"block_id,omitempty"`
+	Reason    string              `json:"reason,omitempty"`
+}
+
+// RoomCalendar lists the bookings of a room within a window, earliest first.
+type RoomCalendar struct {
+	RoomID   primitive.ObjectID `json:"room_id"`
+	RoomName string             `json:"room_name"`
+	From     *time.Time         `json:"from,omitempty"`
+	To       *time.Time         `json:"to,omitempty"`
+	Bookings []RoomBooking      `json:"bookings"`
+}
+
+// examEnd returns when an exam finishes.
+func examEnd(exam *Exam) time.Time {
+	return exam.Date.Add(time.Duration(exam.Duration) * time.Minute)
+}
+
+// periodsOverlap reports whether two periods share any time; periods starting together
+// always do, even when empty.
+func periodsOverlap(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Equal(bStart) || (aStart.Before(bEnd) && bStart.Before(aEnd))
+}
+
+// roomBookings returns the bookings of the given rooms, from exam assignments and
+// manual blocks, keyed by room.
+func (s *SeatingService) roomBookings(ctx context.Context, roomIDs []primitive.ObjectID) (map[primitive.ObjectID][]RoomBooking, error) {
+	bookings := make(map[primitive.ObjectID][]RoomBooking)
+	if len(roomIDs) == 0 {
+		return bookings, nil
+	}
+	examRooms, err := s.repo.FindExamRoomsByRoomIDs(ctx, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+	exams := make(map[primitive.ObjectID]*Exam)
+	for _, examRoom := range examRooms {
+		exam, ok := exams[examRoom.ExamID]
+		if !ok {
+			if exam, err = s.repo.FindExamByID(ctx, examRoom.ExamID); err != nil {
+				return nil, err
+			}
+			exams[examRoom.ExamID] = exam
+		}
+		if exam == nil {
+			continue // Assignment left behind by a deleted exam
+		}
+		examID := exam.ID
+		bookings[examRoom.RoomID] = append(bookings[examRoom.RoomID], RoomBooking{
+			Kind:      BookingExam,
+			Start:     exam.Date,
+			End:       examEnd(exam),
+			ExamID:    &examID,
+			ExamTitle: exam.Title,
+			Shared:    examRoom.Shared,
+			Draft:     examRoom.Draft,
+		})
+	}
+	blocks, err := s.repo.FindRoomBlocksByRoomIDs(ctx, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range blocks {
+		blockID := block.ID
+		bookings[block.RoomID] = append(bookings[block.RoomID], RoomBooking{
+			Kind:    BookingBlock,
+			Start:   block.Start,
+			End:     block.End,
+			BlockID: &blockID,
+			Reason:  block.Reason,
+		})
+	}
+	return bookings, nil
+}
+
+// bookingConflict returns the first booking that keeps exam out of the room, or nil.
+// The exam's own bookings never conflict, and two exams may overlap only when both
+// booked the room as shared.
+func bookingConflict(bookings []RoomBooking, exam *Exam, shared bool) *RoomBooking {
+	for i, booking := range bookings {
+		if booking.ExamID != nil && *booking.ExamID == exam.ID {
+			continue
+		}
+		if !periodsOverlap(exam.Date, examEnd(exam), booking.Start, booking.End) {
+			continue
+		}
+		if booking.Kind == BookingExam && shared && booking.Shared {
+			continue
+		}
+		return &bookings[i]
+	}
+	return nil
+}
+
+// doubleBooked describes a conflicting booking of the named room.
+func doubleBooked(roomName string, booking *RoomBooking) error {
+	const layout = "2006-01-02 15:04"
+	if booking.Kind == BookingBlock {
+		return fmt.Errorf("%w: room %s is blocked from %s to %s (%s)", ErrRoomDoubleBooked, roomName, booking.Start.Format(layout), booking.End.Format(layout), booking.Reason)
+	}
+	return fmt.Errorf("%w: room %s is booked for %q from %s to %s", ErrRoomDoubleBooked, roomName, booking.ExamTitle, booking.Start.Format(layout), booking.End.Format(layout))
+}
+
+// AddRoomToExam assigns a room to an exam unless the room is taken at the exam's time.
+func (s *SeatingService) AddRoomToExam(ctx context.Context, examRoom *ExamRoom) error {
+	exam, err := s.repo.FindExamByID(ctx, examRoom.ExamID)
+	if err != nil {
+		return err
+	}
+	if exam == nil {
+		return errors.New("exam not found")
+	}
+	room, err := s.repo.FindRoomByID(ctx, examRoom.RoomID)
+	if err != nil {
+		return err
+	}
+	if room == nil {
+		return errors.New("room not found")
+	}
+	bookings, err := s.roomBookings(ctx, []primitive.ObjectID{room.ID})
+	if err != nil {
+		return err
+	}
+	if conflict := bookingConflict(bookings[room.ID], exam, examRoom.Shared); conflict != nil {
+		return doubleBooked(room.Name, conflict)
+	}
+	return s.repo.CreateExamRoom(ctx, examRoom)
+}
+
+// UpdateExam saves an exam after checking that its rooms are still free at its new time.
+func (s *SeatingService) UpdateExam(ctx context.Context, exam *Exam) error {
+	examRooms, err := s.repo.GetExamRooms(ctx, exam.ID)
+	if err != nil {
+		return err
+	}
+	roomIDs := make([]primitive.ObjectID, 0, len(examRooms))
+	for _, examRoom := range examRooms {
+		roomIDs = append(roomIDs, examRoom.RoomID)
+	}
+	bookings, err := s.roomBookings(ctx, roomIDs)
+	if err != nil {
+		return err
+	}
+	for _, examRoom := range examRooms {
+		conflict := bookingConflict(bookings[examRoom.RoomID], exam, examRoom.Shared)
+		if conflict == nil {
+			continue
+		}
+		name := examRoom.RoomID.Hex()
+		if room, err := s.repo.FindRoomByID(ctx, examRoom.RoomID); err == nil && room != nil {
+			name = room.Name
+		}
+		return doubleBooked(name, conflict)
+	}
+	return s.repo.UpdateExam(ctx, exam)
+}
+
+// BlockRoom takes a room out of use for a period. Exams already booked in the room
+// during that period have to move first.
+func (s *SeatingService) BlockRoom(ctx context.Context, block *RoomBlock) error {
+	if !block.End.After(block.Start) {
+		return errors.New("block must end after it starts")
+	}
+	room, err := s.repo.FindRoomByID(ctx, block.RoomID)
+	if err != nil {
+		return err
+	}
+	if room == nil {
+		return errors.New("room not found")
+	}
+	bookings, err := s.roomBookings(ctx, []primitive.ObjectID{room.ID})
+	if err != nil {
+		return err
+	}
+	for i, booking := range bookings[room.ID] {
+		if booking.Kind == BookingExam && periodsOverlap(block.Start, block.End, booking.Start, booking.End) {
+			return doubleBooked(room.Name, &bookings[room.ID][i])
+		}
+	}
+	return s.repo.CreateRoomBlock(ctx, block)
+}
+
+// UnblockRoom removes a manual block from a room.
+func (s *SeatingService) UnblockRoom(ctx context.Context, roomID, blockID primitive.ObjectID) error {
+	return s.repo.DeleteRoomBlock(ctx, roomID, blockID)
+}
+
+// GetRoomCalendar returns the bookings of a room that overlap the window from..to.
+// A nil bound leaves that side of the window open.
+func (s *SeatingService) GetRoomCalendar(ctx context.Context, roomID primitive.ObjectID, from, to *time.Time) (*RoomCalendar, error) {
+	room, err := s.repo.FindRoomByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room == nil {
+		return nil, errors.New("room not found")
+	}
+	bookings, err := s.roomBookings(ctx, []primitive.ObjectID{room.ID})
+	if err != nil {
+		return nil, err
+	}
+	calendar := &RoomCalendar{RoomID: room.ID, RoomName: room.Name, From: from, To: to, Bookings: []RoomBooking{}}
+	for _, booking := range bookings[room.ID] {
+		if from != nil && !booking.End.After(*from) && !booking.Start.Equal(*from) {
+			continue
+		}
+		if to != nil && !booking.Start.Before(*to) {
+			continue
+		}
+		calendar.Bookings = append(calendar.Bookings, booking)
+	}
+	sort.SliceStable(calendar.Bookings, func(i, j int) bool {
+		return calendar.Bookings[i].Start.Before(calendar.Bookings[j].Start)
+	})
+	return calendar, nil
+}
+
+// Why: Two exams in one hall at the same hour is only found on the day; keeping exam bookings and manual blocks in one calendar lets every assignment and reschedule be checked up front.
@@ -0,0 +1,199 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxCandidates bounds one batch request so a single call cannot tie up the server.
+	maxCandidates = 12
+	// defaultCandidateSeeds is how many fresh seeds are tried per algorithm when none are given.
+	defaultCandidateSeeds = 3
+)
+
+// CandidateOptions controls a batch of competing plans generated for one exam.
+// Every algorithm is run once per seed; the remaining fields apply to all candidates.
+type CandidateOptions struct {
+	Algorithms   []string        // Registered algorithms to compare
+	Seeds        []int64         // Seeds to try; defaultCandidateSeeds fresh ones when empty
+	SeedCount    int             // Number of fresh seeds to draw when Seeds is empty
+	Parameters   AlgorithmParams // Tunable options shared by all algorithms
+	Mode         string          // GenerationModePerRoom (default) or GenerationModePooled
+	Distribution string          // Cross-room distribution strategy, pooled mode only
+	Spacing      string          // Mandatory spacing rule
+	Grouping     string          // Key students are separated by
+}
+
+// Candidate is one ranked entry of a candidate batch.
+type Candidate struct {
+	Rank int `json:"rank"`
+	*PlanReport
+}
+
+// CandidateBatch is the ranked result of a batch generation request.
+type CandidateBatch struct {
+	Group      primitive.ObjectID `json:"candidate_group"`
+	ExamID     primitive.ObjectID `json:"exam_id"`
+	Candidates []Candidate        `json:"candidates"`
+}
+
+// GenerateCandidatePlans runs every requested algorithm and seed concurrently, scores the
+// results and saves them as ranked drafts sharing one candidate group.
+func (s *SeatingService) GenerateCandidatePlans(ctx context.Context, examID primitive.ObjectID, opts CandidateOptions) (*CandidateBatch, error) {
+	var algorithms []string
+	seen := make(map[string]bool)
+	for _, name := range opts.Algorithms {
+		if seen[name] {
+			continue
+		}
+		if !s.HasAlgorithm(name) {
+			return nil, fmt.Errorf("invalid algorithm specified: %q is not registered", name)
+		}
+		seen[name] = true
+		algorithms = append(algorithms, name)
+	}
+	if len(algorithms) == 0 {
+		return nil, errors.New("at least one algorithm is required")
+	}
+	seeds := opts.Seeds
+	if len(seeds) == 0 {
+		count := opts.SeedCount
+		if count <= 0 {
+			count = defaultCandidateSeeds
+		}
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		for i := 0; i < count; i++ {
+			seeds = append(seeds, rng.Int63())
+		}
+	}
+	total := len(algorithms) * len(seeds)
+	if total > maxCandidates {
+		return nil, fmt.Errorf("too many candidates: %d algorithms x %d seeds exceeds the limit of %d", len(algorithms), len(seeds), maxCandidates)
+	}
+
+	plans := make([]*SeatingPlan, total)
+	errs := make([]error, total)
+	var wg sync.WaitGroup
+	for i, algorithm := range algorithms {
+		for j, seed := range seeds {
+			idx := i*len(seeds) + j
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				plans[idx], errs[idx] = s.buildSeatingPlan(ctx, examID, GenerateOptions{
+					Algorithm:    algorithm,
+					Parameters:   opts.Parameters,
+					Mode:         opts.Mode,
+					Distribution: opts.Distribution,
+					Spacing:      opts.Spacing,
+					Grouping:     opts.Grouping,
+					Seed:         &seed,
+				})
+			}()
+		}
+	}
+	wg.Wait()
+	// Candidates share every input but the algorithm and seed, so one failure means the request itself is wrong
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rankCandidates(plans)
+	group := primitive.NewObjectID()
+	batch := &CandidateBatch{Group: group, ExamID: examID, Candidates: make([]Candidate, 0, len(plans))}
+	for i, plan := range plans {
+		plan.CandidateGroup = &group
+		plan.CandidateRank = i + 1
+		if err := s.repo.CreateSeatingPlan(ctx, plan); err != nil {
+			return nil, err
+		}
+		batch.Candidates = append(batch.Candidates, Candidate{Rank: plan.CandidateRank, PlanReport: newPlanReport(plan)})
+	}
+	return batch, nil
+}
+
+// rankCandidates orders plans best first: fewest unplaced students, then highest
+// quality score, then fewest violations.
+func rankCandidates(plans []*SeatingPlan) {
+	sort.SliceStable(plans, func(i, j int) bool {
+		a, b := plans[i], plans[j]
+		if len(a.UnplacedStudents) != len(b.UnplacedStudents) {
+			return len(a.UnplacedStudents) < len(b.UnplacedStudents)
+		}
+		if a.Score.Score != b.Score.Score {
+			return a.Score.Score > b.Score.Score
+		}
+		return a.Violations < b.Violations
+	})
+}
+
+// GetCandidatePlans returns the ranked candidates of a group.
+func (s *SeatingService) GetCandidatePlans(ctx context.Context, group primitive.ObjectID) (*CandidateBatch, error) {
+	plans, err := s.repo.FindSeatingPlansByCandidateGroup(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return nil, errors.New("candidate group not found")
+	}
+	batch := &CandidateBatch{Group: group, ExamID: plans[0].ExamID, Candidates: make([]Candidate, 0, len(plans))}
+	for _, plan := range plans {
+		batch.Candidates = append(batch.Candidates, Candidate{Rank: plan.CandidateRank, PlanReport: newPlanReport(plan)})
+	}
+	return batch, nil
+}
+
+// PromoteCandidatePlan keeps the chosen candidate as an ordinary draft, numbered as the
+// exam's next version and superseding its latest one, and deletes the rest of its group.
+// It returns the promoted plan and how many candidates were discarded.
+func (s *SeatingService) PromoteCandidatePlan(ctx context.Context, planID primitive.ObjectID) (*SeatingPlan, int64, error) {
+	plan, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if plan == nil {
+		return nil, 0, errors.New("seating plan not found")
+	}
+	if plan.CandidateGroup == nil {
+		return nil, 0, errors.New("seating plan is not a candidate")
+	}
+	version, err := s.repo.NextSeatingPlanVersion(ctx, plan.ExamID)
+	if err != nil {
+		return nil, 0, err
+	}
+	previous, err := s.latestPlanVersion(ctx, plan.ExamID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var previousID *primitive.ObjectID
+	if previous != nil {
+		previousID = &previous.ID
+	}
+	discarded, err := s.repo.PromoteCandidatePlan(ctx, plan.ID, *plan.CandidateGroup, version, previousID)
+	if err != nil {
+		return nil, 0, err
+	}
+	plan.Version = version
+	plan.PreviousPlanID = previousID
+	plan.CandidateGroup = nil
+	plan.CandidateRank = 0
+	plan.UpdatedAt = time.Now()
+	if previous != nil {
+		if err := s.repo.MarkSeatingPlanSuperseded(ctx, previous.ID, plan.ID, plan.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+	}
+	return plan, discarded, nil
+}
+
+// Why: Comparing algorithms and seeds side by side in one call replaces repeated manual generate-and-eyeball rounds, and grouping keeps unchosen drafts easy to discard.
@@ -0,0 +1,159 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ClashExam is one of the overlapping exams a student is listed for.
+type ClashExam struct {
+	ExamID primitive.ObjectID `json:"exam_id"`
+	Title  string             `json:"title"`
+	Start  time.Time          `json:"start"`
+	End    time.Time          `json:"end"`
+}
+
+// StudentClash is a student listed for exams that run at the same time.
+type StudentClash struct {
+	StudentID string      `json:"student_id"`
+	Name      string      `json:"name,omitempty"`
+	Exams     []ClashExam `json:"exams"` // Every exam of the student that overlaps another one, earliest first
+}
+
+// ClashReport lists the students with clashing exams, across the timetable or for one exam.
+type ClashReport struct {
+	ExamID    *primitive.ObjectID `json:"exam_id,omitempty"` // Set when the report is limited to one exam
+	Clashes   []StudentClash      `json:"clashes"`
+	CheckedAt time.Time           `json:"checked_at"`
+}
+
+// examMembership maps every student on the lists assigned to the given exams to those
+// exams, in exam order, and returns the students' names.
+func (s *SeatingService) examMembership(ctx context.Context, exams []*Exam) (map[string][]*Exam, map[string]string, error) {
+	listExams := make(map[primitive.ObjectID][]*Exam)
+	var listIDs []primitive.ObjectID
+	for _, exam := range exams {
+		examRooms, err := s.repo.GetExamRooms(ctx, exam.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, examRoom := range examRooms {
+			for _, id := range examRoom.StudentListIDs {
+				if _, ok := listExams[id]; !ok {
+					listIDs = append(listIDs, id)
+				}
+				listExams[id] = append(listExams[id], exam)
+			}
+		}
+	}
+	membership := make(map[string][]*Exam)
+	names := make(map[string]string)
+	if len(listIDs) == 0 {
+		return membership, names, nil
+	}
+	lists, err := s.repo.FindStudentListsByIDs(ctx, listIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, list := range lists {
+		for _, st := range list.Students {
+			names[st.StudentID] = st.Name
+			for _, exam := range listExams[list.ID] {
+				if !containsExam(membership[st.StudentID], exam) {
+					membership[st.StudentID] = append(membership[st.StudentID], exam)
+				}
+			}
+		}
+	}
+	return membership, names, nil
+}
+
+// containsExam reports whether exam is in exams.
+func containsExam(exams []*Exam, exam *Exam) bool {
+	for _, other := range exams {
+		if other.ID == exam.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// findClashes returns, per student, the exams that overlap another exam of the same
+// student, ordered by student ID.
+func findClashes(membership map[string][]*Exam, names map[string]string) []StudentClash {
+	clashes := []StudentClash{}
+	for studentID, exams := range membership {
+		if len(exams) < 2 {
+			continue
+		}
+		var clashing []ClashExam
+		for i, exam := range exams {
+			for j, other := range exams {
+				if i != j && examsOverlap(exam, other) {
+					clashing = append(clashing, ClashExam{ExamID: exam.ID, Title: exam.Title, Start: exam.Date, End: examEnd(exam)})
+					break
+				}
+			}
+		}
+		if len(clashing) == 0 {
+			continue
+		}
+		sort.SliceStable(clashing, func(i, j int) bool { return clashing[i].Start.Before(clashing[j].Start) })
+		clashes = append(clashes, StudentClash{StudentID: studentID, Name: names[studentID], Exams: clashing})
+	}
+	sort.Slice(clashes, func(i, j int) bool { return clashes[i].StudentID < clashes[j].StudentID })
+	return clashes
+}
+
+// DetectClashes finds students listed, through the exams' room assignments, for exams
+// that overlap in time. With an exam ID only clashes involving that exam are reported.
+func (s *SeatingService) DetectClashes(ctx context.Context, examID *primitive.ObjectID) (*ClashReport, error) {
+	exams, err := s.repo.GetAllExams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &ClashReport{ExamID: examID, CheckedAt: time.Now()}
+	if examID != nil {
+		// Only exams running alongside the given one can clash with it
+		var target *Exam
+		for _, exam := range exams {
+			if exam.ID == *examID {
+				target = exam
+			}
+		}
+		if target == nil {
+			return nil, errors.New("exam not found")
+		}
+		concurrent := []*Exam{target}
+		for _, exam := range exams {
+			if exam.ID != target.ID && examsOverlap(target, exam) {
+				concurrent = append(concurrent, exam)
+			}
+		}
+		exams = concurrent
+	}
+	membership, names, err := s.examMembership(ctx, exams)
+	if err != nil {
+		return nil, err
+	}
+	report.Clashes = findClashes(membership, names)
+	if examID != nil {
+		involved := report.Clashes[:0]
+		for _, clash := range report.Clashes {
+			for _, exam := range clash.Exams {
+				if exam.ExamID == *examID {
+					involved = append(involved, clash)
+					break
+				}
+			}
+		}
+		report.Clashes = involved
+	}
+	return report, nil
+}
+
+// Why: A student listed for two overlapping exams is only noticed when they cannot sit both; checking list membership against exam times whenever either changes surfaces it while the timetable can still move.
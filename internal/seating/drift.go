@@ -0,0 +1,284 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DriftStudent is a student that differs between a plan and the current student lists.
+type DriftStudent struct {
+	StudentID  string        `json:"student_id"`
+	Name       string        `json:"name,omitempty"`
+	Department string        `json:"department"`
+	Batch      string        `json:"batch"`
+	Seat       *SeatPosition `json:"seat,omitempty"` // Current seat of a removed student, if any
+}
+
+// PlanDrift compares a plan with the exam's student lists as they are now.
+type PlanDrift struct {
+	InSync    bool           `json:"in_sync"`
+	Added     []DriftStudent `json:"added"`   // On a list but missing from the plan
+	Removed   []DriftStudent `json:"removed"` // In the plan but no longer on any list
+	CheckedAt time.Time      `json:"checked_at"`
+}
+
+// examRoster holds the students currently on an exam's lists, in list order.
+type examRoster struct {
+	order    []string
+	students map[string]StudentWithGroup
+	rooms    map[string]map[primitive.ObjectID]bool // Rooms each student may sit in; nil for pooled plans
+}
+
+// loadExamRoster gathers the current students of a plan's exam. Per-room plans also
+// remember which rooms list each student, so newcomers stay in their own room.
+func (s *SeatingService) loadExamRoster(ctx context.Context, plan *SeatingPlan) (*examRoster, error) {
+	examRooms, listExams, err := s.sessionExamRooms(ctx, planExamIDs(plan))
+	if err != nil {
+		return nil, err
+	}
+	roster := &examRoster{students: make(map[string]StudentWithGroup)}
+	if plan.Mode != GenerationModePooled {
+		roster.rooms = make(map[string]map[primitive.ObjectID]bool)
+	}
+	for _, examRoom := range examRooms {
+		lists, err := s.repo.FindStudentListsByIDs(ctx, examRoom.StudentListIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range assignGroups(examStudentsFromLists(orderListsByIDs(lists, examRoom.StudentListIDs), listExams), plan.Grouping) {
+			if _, ok := roster.students[st.StudentID]; !ok {
+				roster.students[st.StudentID] = st
+				roster.order = append(roster.order, st.StudentID)
+			}
+			if roster.rooms != nil {
+				if roster.rooms[st.StudentID] == nil {
+					roster.rooms[st.StudentID] = make(map[primitive.ObjectID]bool)
+				}
+				roster.rooms[st.StudentID][examRoom.RoomID] = true
+			}
+		}
+	}
+	return roster, nil
+}
+
+// computeDrift lists students added to or removed from the lists since the plan was made.
+// Students the plan reported as unplaced count as known.
+func computeDrift(plan *SeatingPlan, roster *examRoster) *PlanDrift {
+	drift := &PlanDrift{Added: []DriftStudent{}, Removed: []DriftStudent{}, CheckedAt: time.Now()}
+	known := make(map[string]bool)
+	for _, room := range plan.Rooms {
+		for _, seat := range room.Seats {
+			if seat.IsEmpty || seat.StudentID == "" {
+				continue
+			}
+			known[seat.StudentID] = true
+			if _, ok := roster.students[seat.StudentID]; !ok {
+				drift.Removed = append(drift.Removed, DriftStudent{
+					StudentID:  seat.StudentID,
+					Department: seat.Department,
+					Batch:      seat.Batch,
+					Seat:       &SeatPosition{RoomID: room.RoomID, RoomName: room.Name, Row: seat.Row, Column: seat.Column, Variant: seat.Variant},
+				})
+			}
+		}
+	}
+	for _, st := range plan.UnplacedStudents {
+		known[st.StudentID] = true
+		if _, ok := roster.students[st.StudentID]; !ok {
+			drift.Removed = append(drift.Removed, DriftStudent{StudentID: st.StudentID, Name: st.Name, Department: st.Department, Batch: st.Batch})
+		}
+	}
+	for _, id := range roster.order {
+		if !known[id] {
+			st := roster.students[id]
+			drift.Added = append(drift.Added, DriftStudent{StudentID: id, Name: st.Name, Department: st.Department, Batch: st.Batch})
+		}
+	}
+	drift.InSync = len(drift.Added) == 0 && len(drift.Removed) == 0
+	return drift
+}
+
+// GetPlanDrift compares a plan with the current contents of its exam's student lists.
+func (s *SeatingService) GetPlanDrift(ctx context.Context, planID primitive.ObjectID) (*PlanDrift, error) {
+	plan, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, errors.New("seating plan not found")
+	}
+	roster, err := s.loadExamRoster(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	return computeDrift(plan, roster), nil
+}
+
+// neighbourConflicts counts occupied neighbours (8-neighbourhood) in the same group as
+// the given student under the plan's grouping.
+func neighbourConflicts(occupied map[[2]int]Seat, grouping string, row, col int, st StudentWithGroup) int {
+	count := 0
+	for _, off := range neighbourOffsets {
+		other, ok := occupied[[2]int{row + off[0], col + off[1]}]
+		if !ok {
+			continue
+		}
+		if groupsConflict(seatGroup(grouping, other), st.Group) {
+			count++
+		}
+	}
+	return count
+}
+
+// seatNewcomer puts a student on the free seat with the fewest conflicting neighbours,
+// in any of the allowed rooms (all rooms when allowed is nil) that suits the student;
+// separate rooms only take pooled students who need one. It reports whether a seat
+// was found.
+func seatNewcomer(plan *SeatingPlan, st StudentWithGroup, allowed map[primitive.ObjectID]bool, rooms map[primitive.ObjectID]*Room) bool {
+	var best *Seat
+	bestConflicts := -1
+	for i := range plan.Rooms {
+		room := &plan.Rooms[i]
+		if allowed != nil && !allowed[room.RoomID] {
+			continue
+		}
+		if physical, ok := rooms[room.RoomID]; ok && !roomSuits(physical, st, allowed != nil) {
+			continue
+		}
+		occupied := make(map[[2]int]Seat)
+		for _, seat := range room.Seats {
+			if !seat.IsEmpty && seat.StudentID != "" {
+				occupied[[2]int{seat.Row, seat.Column}] = seat
+			}
+		}
+		for j := range room.Seats {
+			seat := &room.Seats[j]
+			if !seat.IsEmpty || !isSeatable(seatTypeOrUsable(seat.CellType)) {
+				continue
+			}
+			conflicts := neighbourConflicts(occupied, plan.Grouping, seat.Row, seat.Column, st)
+			if bestConflicts < 0 || conflicts < bestConflicts {
+				best, bestConflicts = seat, conflicts
+			}
+		}
+	}
+	if best == nil {
+		return false
+	}
+	seatStudent(best, st)
+	return true
+}
+
+// copyPlanAsNewVersion returns a deep copy of a plan, reset to a fresh unsaved draft.
+func copyPlanAsNewVersion(plan *SeatingPlan) *SeatingPlan {
+	now := time.Now()
+	next := *plan
+	next.ID = primitive.NewObjectID()
+	next.Status = PlanStatusDraft
+	next.StatusHistory = nil
+	next.PreviousPlanID = nil
+	next.SupersededBy = nil
+	next.SupersededAt = nil
+	next.CandidateGroup = nil
+	next.CandidateRank = 0
+	next.CreatedAt = now
+	next.UpdatedAt = now
+	next.Rooms = make([]SeatingPlanRoom, len(plan.Rooms))
+	for i, room := range plan.Rooms {
+		room.Seats = append([]Seat(nil), room.Seats...)
+		next.Rooms[i] = room
+	}
+	next.UnplacedStudents = append([]UnplacedStudent{}, plan.UnplacedStudents...)
+	return &next
+}
+
+// ReseatSeatingPlan brings a plan in line with the current student lists with as few
+// moves as possible: removed students free their seats and newcomers take the best free
+// seat, while everyone else stays put. The result is saved as a new version superseding
+// the plan, and returned together with its diff against it.
+func (s *SeatingService) ReseatSeatingPlan(ctx context.Context, planID primitive.ObjectID) (*SeatingPlan, *PlanDiff, error) {
+	original, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if original == nil {
+		return nil, nil, errors.New("seating plan not found")
+	}
+	if err := checkNotSuperseded(original); err != nil {
+		return nil, nil, err
+	}
+	if original.CandidateGroup != nil {
+		return nil, nil, fmt.Errorf("%w: promote the candidate before re-seating it", ErrPlanNotEditable)
+	}
+	roster, err := s.loadExamRoster(ctx, original)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan := copyPlanAsNewVersion(original)
+	if err := s.annotatePlanSeats(ctx, plan); err != nil {
+		return nil, nil, err
+	}
+	drift := computeDrift(plan, roster)
+	if drift.InSync {
+		return nil, nil, errors.New("seating plan is in sync with student lists")
+	}
+
+	removed := make(map[string]bool, len(drift.Removed))
+	for _, st := range drift.Removed {
+		removed[st.StudentID] = true
+	}
+	for i := range plan.Rooms {
+		for j := range plan.Rooms[i].Seats {
+			seat := &plan.Rooms[i].Seats[j]
+			if removed[seat.StudentID] {
+				*seat = Seat{Row: seat.Row, Column: seat.Column, IsEmpty: true, CellType: seat.CellType}
+			}
+		}
+	}
+	rooms := make(map[primitive.ObjectID]*Room, len(plan.Rooms))
+	for _, planRoom := range plan.Rooms {
+		room, err := s.repo.FindRoomByID(ctx, planRoom.RoomID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if room != nil {
+			rooms[room.ID] = room
+		}
+	}
+	unplaced := plan.UnplacedStudents[:0]
+	for _, st := range plan.UnplacedStudents {
+		if !removed[st.StudentID] {
+			unplaced = append(unplaced, st)
+		}
+	}
+	for _, added := range drift.Added {
+		st := roster.students[added.StudentID]
+		var allowed map[primitive.ObjectID]bool
+		if roster.rooms != nil {
+			allowed = roster.rooms[st.StudentID]
+		}
+		if len(st.Accommodations) > 0 {
+			// Matching rooms and seats are only known at generation
+			unplaced = append(unplaced, unplacedFrom([]StudentWithGroup{st}, "needs accommodations; seat manually or regenerate")...)
+			continue
+		}
+		if !seatNewcomer(plan, st, allowed, rooms) {
+			unplaced = append(unplaced, unplacedFrom([]StudentWithGroup{st}, "no free seat left after list change")...)
+		}
+	}
+	plan.UnplacedStudents = unplaced
+
+	if err := refreshPlanMetrics(plan); err != nil {
+		return nil, nil, err
+	}
+	if err := s.saveNewVersion(ctx, plan, original); err != nil {
+		return nil, nil, err
+	}
+	return plan, diffPlans(plan, original), nil
+}
+
+// Why: Regenerating from scratch for one late registration reshuffles a whole hall; patching only the changed students keeps everyone else's seat and the notification list short.
@@ -0,0 +1,428 @@
+package seating
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Duty swap states. A swap is proposed by one invigilator, accepted by the other and
+// approved by an admin; only approval changes the rooms.
+const (
+	SwapStatusProposed  = "proposed"  // Waiting for the counterpart
+	SwapStatusAccepted  = "accepted"  // Counterpart agreed, waiting for an admin
+	SwapStatusApproved  = "approved"  // Rooms were exchanged
+	SwapStatusDeclined  = "declined"  // Counterpart said no
+	SwapStatusRejected  = "rejected"  // Admin said no
+	SwapStatusCancelled = "cancelled" // Requester withdrew
+)
+
+// swapTransitions lists the states each open swap state may move to.
+var swapTransitions = map[string][]string{
+	SwapStatusProposed: {SwapStatusAccepted, SwapStatusDeclined, SwapStatusRejected, SwapStatusCancelled},
+	SwapStatusAccepted: {SwapStatusApproved, SwapStatusRejected, SwapStatusCancelled},
+}
+
+// ErrInvalidSwap is wrapped when a duty swap cannot be made or cannot move to the requested state.
+var ErrInvalidSwap = errors.New("invalid duty swap")
+
+// ErrDutyNotAssigned is wrapped when an invigilator no longer covers an exam room a swap names.
+var ErrDutyNotAssigned = errors.New("duty no longer assigned")
+
+// Duty is one exam room an invigilator covers.
+type Duty struct {
+	ExamRoomID     primitive.ObjectID `json:"exam_room_id"`
+	ExamID         primitive.ObjectID `json:"exam_id"`
+	ExamTitle      string             `json:"exam_title"`
+	Faculty        string             `json:"faculty"`
+	Start          time.Time          `json:"start"`
+	End            time.Time          `json:"end"`
+	Minutes        int                `json:"minutes"`
+	RoomID         primitive.ObjectID `json:"room_id"`
+	RoomName       string             `json:"room_name"`
+	Building       string             `json:"building"`
+	InvigilatorID  primitive.ObjectID `json:"invigilator_id"`
+	Name           string             `json:"name"`
+	Email          string             `json:"email"`
+	Role           string             `json:"role"`            // See InvigilatorRole* constants
+	CoInvigilators []UserBasicInfo    `json:"co_invigilators"` // Others on duty in the same room
+	Draft          bool               `json:"draft,omitempty"` // Room booked by the timetable generator, not yet confirmed
+}
+
+// buildDuties expands exam rooms into one duty per invigilator, or only for the given
+// invigilator, keeping exams that overlap the window from..to. Duties are ordered by
+// start, room and name.
+func (s *SeatingService) buildDuties(ctx context.Context, examRooms []*ExamRoom, only *primitive.ObjectID, from, to *time.Time) ([]Duty, error) {
+	exams := make(map[primitive.ObjectID]*Exam)
+	rooms := make(map[primitive.ObjectID]*Room)
+	users := make(map[primitive.ObjectID]*User)
+	user := func(id primitive.ObjectID) (*User, error) {
+		if u, ok := users[id]; ok {
+			return u, nil
+		}
+		u, err := s.repo.FindUserByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if u == nil {
+			u = &User{ID: id, Name: id.Hex()} // Account removed since the assignment
+		}
+		users[id] = u
+		return u, nil
+	}
+	var err error
+
+	duties := []Duty{}
+	for _, examRoom := range examRooms {
+		exam, ok := exams[examRoom.ExamID]
+		if !ok {
+			if exam, err = s.repo.FindExamByID(ctx, examRoom.ExamID); err != nil {
+				return nil, err
+			}
+			exams[examRoom.ExamID] = exam
+		}
+		if exam == nil {
+			continue // Assignment left behind by a deleted exam
+		}
+		start, end := exam.Date, examEnd(exam)
+		if from != nil && !end.After(*from) && !start.Equal(*from) {
+			continue
+		}
+		if to != nil && !start.Before(*to) {
+			continue
+		}
+		room, ok := rooms[examRoom.RoomID]
+		if !ok {
+			if room, err = s.repo.FindRoomByID(ctx, examRoom.RoomID); err != nil {
+				return nil, err
+			}
+			if room == nil {
+				room = &Room{ID: examRoom.RoomID, Name: examRoom.RoomID.Hex()}
+			}
+			rooms[examRoom.RoomID] = room
+		}
+
+		for _, id := range examRoom.Invigilators {
+			if only != nil && id != *only {
+				continue
+			}
+			invigilator, err := user(id)
+			if err != nil {
+				return nil, err
+			}
+			duty := Duty{
+				ExamRoomID:     examRoom.ID,
+				ExamID:         exam.ID,
+				ExamTitle:      exam.Title,
+				Faculty:        exam.Faculty,
+				Start:          start,
+				End:            end,
+				Minutes:        exam.Duration,
+				RoomID:         room.ID,
+				RoomName:       room.Name,
+				Building:       room.Building,
+				InvigilatorID:  id,
+				Name:           invigilator.Name,
+				Email:          invigilator.Email,
+				Role:           invigilatorRole(examRoom, id),
+				CoInvigilators: []UserBasicInfo{},
+				Draft:          examRoom.Draft,
+			}
+			for _, otherID := range examRoom.Invigilators {
+				if otherID == id {
+					continue
+				}
+				other, err := user(otherID)
+				if err != nil {
+					return nil, err
+				}
+				duty.CoInvigilators = append(duty.CoInvigilators, UserBasicInfo{ID: other.ID, Name: other.Name, Role: invigilatorRole(examRoom, otherID)})
+			}
+			duties = append(duties, duty)
+		}
+	}
+	sort.SliceStable(duties, func(i, j int) bool {
+		a, b := duties[i], duties[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.RoomName != b.RoomName {
+			return a.RoomName < b.RoomName
+		}
+		return a.Name < b.Name
+	})
+	return duties, nil
+}
+
+// GetMyDuties returns the duties of the member of staff with the given email within
+// the window from..to; nil bounds leave it open.
+func (s *SeatingService) GetMyDuties(ctx context.Context, email string, from, to *time.Time) ([]Duty, error) {
+	user, err := s.staffByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	examRooms, err := s.repo.FindExamRoomsByInvigilator(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildDuties(ctx, examRooms, &user.ID, from, to)
+}
+
+// DutyRoster returns every invigilator duty of the term from..to.
+func (s *SeatingService) DutyRoster(ctx context.Context, from, to *time.Time) ([]Duty, error) {
+	examRooms, err := s.repo.GetAllExamRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildDuties(ctx, examRooms, nil, from, to)
+}
+
+// WriteDutyRosterCSV writes duties as CSV with a header row, one duty per line.
+func WriteDutyRosterCSV(w io.Writer, duties []Duty) error {
+	out := csv.NewWriter(w)
+	header := []string{"date", "start", "end", "exam", "faculty", "building", "room", "invigilator", "email", "role", "minutes", "co_invigilators", "draft"}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, duty := range duties {
+		others := ""
+		for i, other := range duty.CoInvigilators {
+			if i > 0 {
+				others += "; "
+			}
+			others += other.Name
+		}
+		record := []string{
+			duty.Start.Format("2006-01-02"),
+			duty.Start.Format("15:04"),
+			duty.End.Format("15:04"),
+			duty.ExamTitle,
+			duty.Faculty,
+			duty.Building,
+			duty.RoomName,
+			duty.Name,
+			duty.Email,
+			duty.Role,
+			strconv.Itoa(duty.Minutes),
+			others,
+			strconv.FormatBool(duty.Draft),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// dutyClash rejects moving an invigilator into exam when they are on duty at an
+// overlapping exam in any room other than the one they are leaving.
+func (s *SeatingService) dutyClash(ctx context.Context, invigilatorID primitive.ObjectID, email string, leaving primitive.ObjectID, exam *Exam) error {
+	examRooms, err := s.repo.FindExamRoomsByInvigilator(ctx, invigilatorID)
+	if err != nil {
+		return err
+	}
+	for _, examRoom := range examRooms {
+		if examRoom.ID == leaving {
+			continue
+		}
+		other, err := s.repo.FindExamByID(ctx, examRoom.ExamID)
+		if err != nil {
+			return err
+		}
+		if other != nil && examsOverlap(other, exam) {
+			return fmt.Errorf("%w: %s would also be on duty at %q", ErrInvalidSwap, email, other.Title)
+		}
+	}
+	return nil
+}
+
+// checkDutySwap verifies that both invigilators still hold the duties being traded and
+// that neither ends up double-booked or on duty while declared unavailable.
+func (s *SeatingService) checkDutySwap(ctx context.Context, swap *DutySwap) error {
+	given, err := s.repo.FindExamRoomByID(ctx, swap.RequesterExamRoomID)
+	if err != nil {
+		return err
+	}
+	taken, err := s.repo.FindExamRoomByID(ctx, swap.CounterpartExamRoomID)
+	if err != nil {
+		return err
+	}
+	if given == nil || taken == nil {
+		return errors.New("exam room not found")
+	}
+	if !containsObjectID(given.Invigilators, swap.RequesterID) {
+		return fmt.Errorf("%w: %s is not on duty in that room", ErrInvalidSwap, swap.RequesterEmail)
+	}
+	if !containsObjectID(taken.Invigilators, swap.CounterpartID) {
+		return fmt.Errorf("%w: %s is not on duty in that room", ErrInvalidSwap, swap.CounterpartEmail)
+	}
+	if containsObjectID(given.Invigilators, swap.CounterpartID) || containsObjectID(taken.Invigilators, swap.RequesterID) {
+		return fmt.Errorf("%w: both invigilators are already on duty in the same room", ErrInvalidSwap)
+	}
+
+	givenExam, err := s.repo.FindExamByID(ctx, given.ExamID)
+	if err != nil {
+		return err
+	}
+	takenExam, err := s.repo.FindExamByID(ctx, taken.ExamID)
+	if err != nil {
+		return err
+	}
+	if givenExam == nil || takenExam == nil {
+		return errors.New("exam not found")
+	}
+	if err := s.dutyClash(ctx, swap.RequesterID, swap.RequesterEmail, given.ID, takenExam); err != nil {
+		return err
+	}
+	if err := s.dutyClash(ctx, swap.CounterpartID, swap.CounterpartEmail, taken.ID, givenExam); err != nil {
+		return err
+	}
+	if err := s.CheckInvigilatorAvailable(ctx, takenExam.ID, swap.RequesterID); err != nil {
+		return err
+	}
+	return s.CheckInvigilatorAvailable(ctx, givenExam.ID, swap.CounterpartID)
+}
+
+// ProposeDutySwap records a request by the member of staff with the given email to
+// trade swap.RequesterExamRoomID for the counterpart's swap.CounterpartExamRoomID.
+func (s *SeatingService) ProposeDutySwap(ctx context.Context, email string, swap *DutySwap) error {
+	requester, err := s.staffByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	counterpart, err := s.repo.FindUserByID(ctx, swap.CounterpartID)
+	if err != nil {
+		return err
+	}
+	if counterpart == nil {
+		return errors.New("invigilator not found")
+	}
+	if counterpart.ID == requester.ID {
+		return fmt.Errorf("%w: cannot swap with yourself", ErrInvalidSwap)
+	}
+	swap.RequesterID, swap.RequesterEmail = requester.ID, requester.Email
+	swap.CounterpartEmail = counterpart.Email
+	if err := s.checkDutySwap(ctx, swap); err != nil {
+		return err
+	}
+	swap.Status = SwapStatusProposed
+	return s.repo.CreateDutySwap(ctx, swap)
+}
+
+// GetMyDutySwaps lists the swaps the member of staff proposed or was asked to take part in.
+func (s *SeatingService) GetMyDutySwaps(ctx context.Context, email, status string) ([]*DutySwap, error) {
+	user, err := s.staffByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindDutySwaps(ctx, &user.ID, status)
+}
+
+// ListDutySwaps lists swaps for admins, optionally for one invigilator and one status.
+func (s *SeatingService) ListDutySwaps(ctx context.Context, invigilatorID *primitive.ObjectID, status string) ([]*DutySwap, error) {
+	return s.repo.FindDutySwaps(ctx, invigilatorID, status)
+}
+
+// transitionDutySwap moves a swap to a new state. Approval re-checks the swap and
+// exchanges the rooms together with the status change.
+func (s *SeatingService) transitionDutySwap(ctx context.Context, swap *DutySwap, to, decidedBy string) (*DutySwap, error) {
+	allowed := false
+	for _, next := range swapTransitions[swap.Status] {
+		allowed = allowed || next == to
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: swap is %s and cannot become %s", ErrInvalidSwap, swap.Status, to)
+	}
+	switch to {
+	case SwapStatusAccepted:
+		if err := s.checkDutySwap(ctx, swap); err != nil {
+			return nil, err
+		}
+		err := s.repo.UpdateDutySwapStatus(ctx, swap.ID, swap.Status, to, "")
+		if err != nil {
+			return nil, err
+		}
+	case SwapStatusApproved:
+		if err := s.checkDutySwap(ctx, swap); err != nil {
+			return nil, err
+		}
+		if err := s.repo.ApplyDutySwap(ctx, swap, decidedBy); err != nil {
+			if errors.Is(err, ErrDutyNotAssigned) {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidSwap, err)
+			}
+			return nil, err
+		}
+	default:
+		if err := s.repo.UpdateDutySwapStatus(ctx, swap.ID, swap.Status, to, decidedBy); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.FindDutySwapByID(ctx, swap.ID)
+}
+
+// findDutySwap loads a swap, treating swaps the caller is not part of as missing.
+func (s *SeatingService) findDutySwap(ctx context.Context, id primitive.ObjectID, party func(*DutySwap) bool) (*DutySwap, error) {
+	swap, err := s.repo.FindDutySwapByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if swap == nil || (party != nil && !party(swap)) {
+		return nil, errors.New("duty swap not found")
+	}
+	return swap, nil
+}
+
+// RespondToDutySwap lets the counterpart accept or decline a proposed swap.
+func (s *SeatingService) RespondToDutySwap(ctx context.Context, email string, id primitive.ObjectID, accept bool) (*DutySwap, error) {
+	user, err := s.staffByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	swap, err := s.findDutySwap(ctx, id, func(swap *DutySwap) bool { return swap.CounterpartID == user.ID })
+	if err != nil {
+		return nil, err
+	}
+	if swap.Status != SwapStatusProposed {
+		return nil, fmt.Errorf("%w: swap is %s", ErrInvalidSwap, swap.Status)
+	}
+	if accept {
+		return s.transitionDutySwap(ctx, swap, SwapStatusAccepted, "")
+	}
+	return s.transitionDutySwap(ctx, swap, SwapStatusDeclined, "")
+}
+
+// CancelDutySwap lets the requester withdraw a swap that is not decided yet.
+func (s *SeatingService) CancelDutySwap(ctx context.Context, email string, id primitive.ObjectID) (*DutySwap, error) {
+	user, err := s.staffByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	swap, err := s.findDutySwap(ctx, id, func(swap *DutySwap) bool { return swap.RequesterID == user.ID })
+	if err != nil {
+		return nil, err
+	}
+	return s.transitionDutySwap(ctx, swap, SwapStatusCancelled, "")
+}
+
+// DecideDutySwap lets an admin approve an accepted swap, exchanging the rooms, or
+// reject an open one.
+func (s *SeatingService) DecideDutySwap(ctx context.Context, id primitive.ObjectID, approve bool, adminEmail string) (*DutySwap, error) {
+	swap, err := s.findDutySwap(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if approve {
+		return s.transitionDutySwap(ctx, swap, SwapStatusApproved, adminEmail)
+	}
+	return s.transitionDutySwap(ctx, swap, SwapStatusRejected, adminEmail)
+}
+
+// Why: Staff could not see their own duties or hand one over without asking an admin to edit rooms by hand; a roster plus a propose-accept-approve swap keeps every change agreed and clash-free.
@@ -0,0 +1,383 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidSeatEdit is wrapped by every rejected manual seat change.
+var ErrInvalidSeatEdit = errors.New("invalid seat edit")
+
+// SeatRef identifies one seat of a plan.
+type SeatRef struct {
+	RoomID primitive.ObjectID `json:"room_id"`
+	Row    int                `json:"row"`    // Row number (1-based)
+	Column int                `json:"column"` // Column number (1-based)
+}
+
+// LockedSeat pins a student to a seat across regenerations.
+type LockedSeat struct {
+	RoomID    primitive.ObjectID
+	Row       int
+	Column    int
+	StudentID string
+}
+
+// lockedSeatsOf returns the locked, occupied seats of a plan.
+func lockedSeatsOf(plan *SeatingPlan) []LockedSeat {
+	var locks []LockedSeat
+	for _, room := range plan.Rooms {
+		for _, seat := range room.Seats {
+			if seat.Locked && !seat.IsEmpty && seat.StudentID != "" {
+				locks = append(locks, LockedSeat{RoomID: room.RoomID, Row: seat.Row, Column: seat.Column, StudentID: seat.StudentID})
+			}
+		}
+	}
+	return locks
+}
+
+// matchLocks assigns locks to the rooms of a run, dropping any whose room is no longer
+// assigned or whose cell can no longer be seated. It also returns the locked student IDs.
+func matchLocks(rooms []*Room, locks []LockedSeat) ([][]LockedSeat, map[string]bool) {
+	byRoom := make([][]LockedSeat, len(rooms))
+	ids := make(map[string]bool)
+	taken := make(map[string]bool)
+	for _, lock := range locks {
+		if ids[lock.StudentID] {
+			continue
+		}
+		for i, room := range rooms {
+			if room.ID != lock.RoomID {
+				continue
+			}
+			if lock.Row < 1 || lock.Row > room.Rows || lock.Column < 1 || lock.Column > room.Columns {
+				break
+			}
+			cell := fmt.Sprintf("%d/%d/%d", i, lock.Row, lock.Column)
+			if taken[cell] || !isSeatable(room.cellTypes()[(lock.Row-1)*room.Columns+lock.Column-1]) {
+				break
+			}
+			taken[cell] = true
+			ids[lock.StudentID] = true
+			byRoom[i] = append(byRoom[i], lock)
+			break
+		}
+	}
+	return byRoom, ids
+}
+
+// reserveLockedCells returns a copy of the room whose locked (or otherwise reserved)
+// cells are taken out of the seatable area, so algorithms and capacity leave them alone.
+func reserveLockedCells(room *Room, locks []LockedSeat) *Room {
+	if len(locks) == 0 {
+		return room
+	}
+	reserved := *room
+	reserved.Layout = append([]LayoutCell(nil), room.Layout...)
+	for _, lock := range locks {
+		replaced := false
+		for k := range reserved.Layout {
+			if reserved.Layout[k].Row == lock.Row && reserved.Layout[k].Column == lock.Column {
+				reserved.Layout[k].Type = CellLocked
+				replaced = true
+			}
+		}
+		if !replaced {
+			reserved.Layout = append(reserved.Layout, LayoutCell{Row: lock.Row, Column: lock.Column, Type: CellLocked})
+		}
+	}
+	reserved.Capacity = reserved.SeatableCount()
+	return &reserved
+}
+
+// placeReservedSeats puts students back on the cells reserved for them (locked or
+// accommodated seats) and restores the cells' real types. Reservations for students no
+// longer on any list leave the seat empty. The seats are marked Locked when locked is set.
+// It returns the students that were placed.
+func placeReservedSeats(seats []Seat, room *Room, locks []LockedSeat, students map[string]StudentWithGroup, locked bool) []StudentWithGroup {
+	types := room.cellTypes()
+	var placed []StudentWithGroup
+	for _, lock := range locks {
+		idx := (lock.Row-1)*room.Columns + lock.Column - 1
+		if idx < 0 || idx >= len(seats) {
+			continue
+		}
+		seats[idx].CellType = seatCellType(types[idx])
+		st, ok := students[lock.StudentID]
+		if !ok {
+			continue
+		}
+		seats[idx].StudentID = st.StudentID
+		seats[idx].IsEmpty = false
+		seats[idx].Locked = locked
+		placed = append(placed, st)
+	}
+	return placed
+}
+
+// findSeat locates a seat in a plan.
+func findSeat(plan *SeatingPlan, ref SeatRef) (*SeatingPlanRoom, *Seat, error) {
+	for i := range plan.Rooms {
+		room := &plan.Rooms[i]
+		if room.RoomID != ref.RoomID {
+			continue
+		}
+		for j := range room.Seats {
+			if room.Seats[j].Row == ref.Row && room.Seats[j].Column == ref.Column {
+				return room, &room.Seats[j], nil
+			}
+		}
+		return nil, nil, fmt.Errorf("%w: seat (%d,%d) does not exist in room %s", ErrInvalidSeatEdit, ref.Row, ref.Column, room.Name)
+	}
+	return nil, nil, fmt.Errorf("%w: room %s is not part of this plan", ErrInvalidSeatEdit, ref.RoomID.Hex())
+}
+
+// checkSeatable rejects seats that cannot hold a student, either in the plan (spacing,
+// aisles) or in the room's current layout.
+func (s *SeatingService) checkSeatable(ctx context.Context, room *SeatingPlanRoom, seat *Seat) error {
+	if !isSeatable(seatTypeOrUsable(seat.CellType)) {
+		return fmt.Errorf("%w: seat (%d,%d) in room %s is %s", ErrInvalidSeatEdit, seat.Row, seat.Column, room.Name, seat.CellType)
+	}
+	current, err := s.repo.FindRoomByID(ctx, room.RoomID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return nil // Room deleted since generation; the plan's own layout is all we have
+	}
+	if seat.Row > current.Rows || seat.Column > current.Columns {
+		return fmt.Errorf("%w: seat (%d,%d) is outside the current layout of room %s", ErrInvalidSeatEdit, seat.Row, seat.Column, room.Name)
+	}
+	if t := current.cellTypes()[(seat.Row-1)*current.Columns+seat.Column-1]; !isSeatable(t) {
+		return fmt.Errorf("%w: seat (%d,%d) in room %s is now %s", ErrInvalidSeatEdit, seat.Row, seat.Column, room.Name, t)
+	}
+	return nil
+}
+
+// loadPlanForEdit fetches a plan that may still be edited, with department and batch
+// filled in on every seat.
+func (s *SeatingService) loadPlanForEdit(ctx context.Context, planID primitive.ObjectID) (*SeatingPlan, error) {
+	plan, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, errors.New("seating plan not found")
+	}
+	if err := checkPlanEditable(plan); err != nil {
+		return nil, err
+	}
+	if err := checkNotSuperseded(plan); err != nil {
+		return nil, err
+	}
+	if err := s.annotatePlanSeats(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// saveEditedPlan refreshes a plan's metrics and stores it.
+func (s *SeatingService) saveEditedPlan(ctx context.Context, plan *SeatingPlan) error {
+	if err := refreshPlanMetrics(plan); err != nil {
+		return err
+	}
+	plan.UpdatedAt = time.Now()
+	return s.repo.UpdateSeatingPlan(ctx, plan)
+}
+
+// refreshPlanMetrics checks that no student is seated twice, gives newly seated students
+// a paper variant and recomputes violations and scores from the annotated seats.
+func refreshPlanMetrics(plan *SeatingPlan) error {
+	seen := make(map[string]bool)
+	total := 0
+	for i := range plan.Rooms {
+		room := &plan.Rooms[i]
+		var students []StudentWithGroup
+		for _, seat := range room.Seats {
+			if seat.IsEmpty || seat.StudentID == "" {
+				continue
+			}
+			if seen[seat.StudentID] {
+				return fmt.Errorf("%w: student %s would be seated more than once", ErrInvalidSeatEdit, seat.StudentID)
+			}
+			seen[seat.StudentID] = true
+			students = append(students, occupantOf(&seat))
+		}
+		room.Violations = countSeatViolations(room.Seats, assignGroups(students, plan.Grouping))
+		assignPaperVariants(room.Seats, plan.PaperSets)
+		room.VariantCounts = countPaperVariants(room.Seats)
+		total += room.Violations
+	}
+	plan.Violations = total
+	scorePlan(plan)
+	return nil
+}
+
+// occupantOf returns the student on a seat, as far as the seat records them.
+func occupantOf(seat *Seat) StudentWithGroup {
+	st := StudentWithGroup{StudentID: seat.StudentID, Department: seat.Department, Batch: seat.Batch, Course: seat.Course}
+	if seat.ExamID != nil {
+		st.ExamID = *seat.ExamID
+	}
+	return st
+}
+
+// seatStudent puts a student on a seat together with the details seats keep about them.
+func seatStudent(seat *Seat, st StudentWithGroup) {
+	seat.StudentID, seat.Department, seat.Batch, seat.Course, seat.IsEmpty = st.StudentID, st.Department, st.Batch, st.Course, false
+	seat.ExamID = nil
+	if !st.ExamID.IsZero() {
+		examID := st.ExamID
+		seat.ExamID = &examID
+	}
+}
+
+// moveOccupant transfers the student on from onto the empty seat to. Paper variants stay
+// with the seat; the vacated seat drops its variant and the target gets one on save.
+func moveOccupant(from, to *Seat) {
+	seatStudent(to, occupantOf(from))
+	from.StudentID, from.Department, from.Batch, from.Course, from.Variant, from.ExamID, from.IsEmpty = "", "", "", "", "", nil, true
+}
+
+// SwapSeats exchanges the occupants of two seats, which may be in different rooms.
+// One of the seats may be empty, which makes the swap a move.
+func (s *SeatingService) SwapSeats(ctx context.Context, planID primitive.ObjectID, a, b SeatRef) (*SeatingPlan, error) {
+	plan, err := s.loadPlanForEdit(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	roomA, seatA, err := findSeat(plan, a)
+	if err != nil {
+		return nil, err
+	}
+	roomB, seatB, err := findSeat(plan, b)
+	if err != nil {
+		return nil, err
+	}
+	if seatA == seatB {
+		return nil, fmt.Errorf("%w: cannot swap a seat with itself", ErrInvalidSeatEdit)
+	}
+	if seatA.Locked || seatB.Locked {
+		return nil, fmt.Errorf("%w: locked seats cannot be swapped", ErrInvalidSeatEdit)
+	}
+	if seatA.IsEmpty && seatB.IsEmpty {
+		return nil, fmt.Errorf("%w: both seats are empty", ErrInvalidSeatEdit)
+	}
+	// Each seat receiving a student must be able to hold one
+	if !seatA.IsEmpty {
+		if err := s.checkSeatable(ctx, roomB, seatB); err != nil {
+			return nil, err
+		}
+	}
+	if !seatB.IsEmpty {
+		if err := s.checkSeatable(ctx, roomA, seatA); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case seatA.IsEmpty:
+		moveOccupant(seatB, seatA)
+	case seatB.IsEmpty:
+		moveOccupant(seatA, seatB)
+	default:
+		a, b := occupantOf(seatA), occupantOf(seatB)
+		seatStudent(seatA, b)
+		seatStudent(seatB, a)
+	}
+	if err := s.saveEditedPlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// MoveStudent puts a student on an empty seat, in any room of the plan. Students that
+// generation could not seat are taken off the unplaced list.
+func (s *SeatingService) MoveStudent(ctx context.Context, planID primitive.ObjectID, studentID string, to SeatRef) (*SeatingPlan, error) {
+	plan, err := s.loadPlanForEdit(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	targetRoom, target, err := findSeat(plan, to)
+	if err != nil {
+		return nil, err
+	}
+	if target.Locked {
+		return nil, fmt.Errorf("%w: seat (%d,%d) is locked", ErrInvalidSeatEdit, target.Row, target.Column)
+	}
+	if !target.IsEmpty {
+		return nil, fmt.Errorf("%w: seat (%d,%d) is already taken by %s", ErrInvalidSeatEdit, target.Row, target.Column, target.StudentID)
+	}
+	if err := s.checkSeatable(ctx, targetRoom, target); err != nil {
+		return nil, err
+	}
+
+	var source *Seat
+	for i := range plan.Rooms {
+		for j := range plan.Rooms[i].Seats {
+			seat := &plan.Rooms[i].Seats[j]
+			if seat.IsEmpty || seat.StudentID != studentID {
+				continue
+			}
+			if source != nil {
+				return nil, fmt.Errorf("%w: student %s is seated more than once", ErrInvalidSeatEdit, studentID)
+			}
+			source = seat
+		}
+	}
+	if source != nil {
+		if source.Locked {
+			return nil, fmt.Errorf("%w: student %s has a locked seat", ErrInvalidSeatEdit, studentID)
+		}
+		moveOccupant(source, target)
+	} else {
+		idx := -1
+		for i, st := range plan.UnplacedStudents {
+			if st.StudentID == studentID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: student %s is not part of this plan", ErrInvalidSeatEdit, studentID)
+		}
+		st := plan.UnplacedStudents[idx]
+		placed := StudentWithGroup{StudentID: st.StudentID, Department: st.Department, Batch: st.Batch, Course: st.Course}
+		if st.ExamID != nil {
+			placed.ExamID = *st.ExamID
+		}
+		seatStudent(target, placed)
+		plan.UnplacedStudents = append(plan.UnplacedStudents[:idx], plan.UnplacedStudents[idx+1:]...)
+	}
+	if err := s.saveEditedPlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// SetSeatLocks locks or unlocks seats. Only occupied seats can be locked.
+func (s *SeatingService) SetSeatLocks(ctx context.Context, planID primitive.ObjectID, refs []SeatRef, locked bool) (*SeatingPlan, error) {
+	plan, err := s.loadPlanForEdit(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		_, seat, err := findSeat(plan, ref)
+		if err != nil {
+			return nil, err
+		}
+		if locked && (seat.IsEmpty || seat.StudentID == "") {
+			return nil, fmt.Errorf("%w: seat (%d,%d) is empty and cannot be locked", ErrInvalidSeatEdit, seat.Row, seat.Column)
+		}
+		seat.Locked = locked
+	}
+	if err := s.saveEditedPlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Why: Generated plans are rarely perfect; small manual fixes with locks that survive regeneration avoid throwing away a good arrangement for one student's sake.
@@ -0,0 +1,99 @@
+package seating
+
+import "strings"
+
+// Grouping keys deciding which students count as the same group, i.e. must not sit
+// next to each other and are kept together or spread out by the algorithms.
+const (
+	GroupByDepartmentOrBatch = "department_or_batch" // Same department or same batch (default)
+	GroupByDepartment        = "department"          // Same department
+	GroupByBatch             = "batch"               // Same batch, across departments
+	GroupByDepartmentBatch   = "department_batch"    // Same department and batch; other batches of a department may mix
+	GroupByCourse            = "course"              // Same course or paper being sat
+	GroupByExam              = "exam"                // Same exam; used by session plans seating several exams together
+)
+
+// Groupings lists the grouping keys accepted by plan generation.
+var Groupings = []string{GroupByDepartmentOrBatch, GroupByDepartment, GroupByBatch, GroupByDepartmentBatch, GroupByCourse, GroupByExam}
+
+// groupSeparator joins the keys of a group that conflicts on any one of them.
+const groupSeparator = "|"
+
+// IsValidGrouping reports whether name is a known grouping key; empty means department or batch.
+func IsValidGrouping(name string) bool {
+	if name == "" {
+		return true
+	}
+	for _, g := range Groupings {
+		if g == name {
+			return true
+		}
+	}
+	return false
+}
+
+// groupKey returns the separation group of a student under the given grouping.
+// Students missing the chosen field fall back to their department.
+func groupKey(grouping string, st StudentWithGroup) string {
+	switch grouping {
+	case GroupByDepartmentOrBatch, "":
+		if st.Batch != "" {
+			return "department:" + st.Department + groupSeparator + "batch:" + st.Batch
+		}
+	case GroupByBatch:
+		if st.Batch != "" {
+			return "batch:" + st.Batch
+		}
+	case GroupByDepartmentBatch:
+		return "department:" + st.Department + "/" + st.Batch
+	case GroupByCourse:
+		if st.Course != "" {
+			return "course:" + st.Course
+		}
+	case GroupByExam:
+		if !st.ExamID.IsZero() {
+			return "exam:" + st.ExamID.Hex()
+		}
+	}
+	return "department:" + st.Department
+}
+
+// groupsConflict reports whether students of two groups may not sit next to each other:
+// they share the whole group or, for department-or-batch groups, any one of its keys.
+func groupsConflict(a, b string) bool {
+	if a == b {
+		return true
+	}
+	for _, x := range strings.Split(a, groupSeparator) {
+		for _, y := range strings.Split(b, groupSeparator) {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// groupBucket returns the first key of a group, which the interleaving algorithms
+// spread students by.
+func groupBucket(group string) string {
+	if i := strings.Index(group, groupSeparator); i >= 0 {
+		return group[:i]
+	}
+	return group
+}
+
+// assignGroups sets Group on every student for the given grouping and returns the slice.
+func assignGroups(students []StudentWithGroup, grouping string) []StudentWithGroup {
+	for i := range students {
+		students[i].Group = groupKey(grouping, students[i])
+	}
+	return students
+}
+
+// seatGroup returns the separation group of the student on an occupied seat.
+func seatGroup(grouping string, seat Seat) string {
+	return groupKey(grouping, occupantOf(&seat))
+}
+
+// Why: Mixed exams put batches of one department on different papers; a configurable key lets every algorithm separate by what actually makes copying possible.
@@ -2,9 +2,13 @@ package seating
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"reflect"
+	"strconv"
 	"time"
 
 	"ExamSeatPlanner/internal/auth"
@@ -26,29 +30,117 @@ func NewSeatingHandler(service *SeatingService) *SeatingHandler {
 
 // GenerateSeatingPlanRequest represents the request to generate a seating plan.
 type GenerateSeatingPlanRequest struct {
-	ExamID           string   `json:"exam_id"`           // Exam ID
-	RoomID           string   `json:"room_id"`           // Room ID
-	InvigilatorEmail string   `json:"invigilator_email"` // Invigilator email
-	Algorithm        string   `json:"algorithm"`         // Algorithm to use (matrix, parallel, random)
-	StudentIDs       []string `json:"student_ids"`       // List of student IDs
+	ExamID           string          `json:"exam_id"`           // Exam ID
+	RoomID           string          `json:"room_id"`           // Room ID
+	InvigilatorEmail string          `json:"invigilator_email"` // Invigilator email
+	Algorithm        string          `json:"algorithm"`         // Algorithm to use (see GET /api/seating/algorithms)
+	StudentIDs       []string        `json:"student_ids"`       // List of student IDs
+	Parameters       AlgorithmParams `json:"parameters"`        // Tunable algorithm options
+	Mode             string          `json:"mode"`              // Generation mode: per_room (default) or pooled
+	Distribution     string          `json:"distribution"`      // Cross-room strategy for pooled mode (matrix, random, parallel)
+	Spacing          string          `json:"spacing"`           // Spacing rule: none, every_other_column, checkerboard
+	Grouping         string          `json:"grouping"`          // Separation key: department_or_batch (default), department, batch, department_batch, course, exam
+	Seed             *int64          `json:"seed"`              // Optional seed; the same seed and inputs reproduce the same plan
+}
+
+// GenerateCandidatesRequest represents the request to generate competing candidate plans.
+type GenerateCandidatesRequest struct {
+	ExamID       string          `json:"exam_id"`      // Exam ID
+	Algorithms   []string        `json:"algorithms"`   // Algorithms to compare (see GET /api/seating/algorithms)
+	Seeds        []int64         `json:"seeds"`        // Seeds to try with every algorithm
+	SeedCount    int             `json:"seed_count"`   // Number of fresh seeds when seeds is empty (default 3)
+	Parameters   AlgorithmParams `json:"parameters"`   // Tunable algorithm options shared by all candidates
+	Mode         string          `json:"mode"`         // Generation mode: per_room (default) or pooled
+	Distribution string          `json:"distribution"` // Cross-room strategy for pooled mode (matrix, random, parallel)
+	Spacing      string          `json:"spacing"`      // Spacing rule: none, every_other_column, checkerboard
+	Grouping     string          `json:"grouping"`     // Separation key: department_or_batch (default), department, batch, department_batch, course, exam
+}
+
+// GenerateSessionRequest represents the request to seat several concurrent exams together.
+type GenerateSessionRequest struct {
+	ExamIDs      []string        `json:"exam_ids"`     // Exams held at the same time in shared rooms; the plan belongs to the first
+	Algorithm    string          `json:"algorithm"`    // Algorithm to use (see GET /api/seating/algorithms)
+	Parameters   AlgorithmParams `json:"parameters"`   // Tunable algorithm options
+	Mode         string          `json:"mode"`         // Generation mode: per_room (default) or pooled
+	Distribution string          `json:"distribution"` // Cross-room strategy for pooled mode (matrix, random, parallel)
+	Spacing      string          `json:"spacing"`      // Spacing rule: none, every_other_column, checkerboard
+	Seed         *int64          `json:"seed"`         // Optional seed; the same seed and inputs reproduce the same plan
+}
+
+// SwapSeatsRequest represents the request to exchange two seats of a plan.
+type SwapSeatsRequest struct {
+	A SeatRef `json:"a"` // First seat
+	B SeatRef `json:"b"` // Second seat
+}
+
+// MoveStudentRequest represents the request to put a student on an empty seat.
+type MoveStudentRequest struct {
+	StudentID string  `json:"student_id"` // Student to move, seated or unplaced
+	To        SeatRef `json:"to"`         // Empty destination seat
+}
+
+// SeatLockRequest represents the request to lock or unlock seats.
+type SeatLockRequest struct {
+	Seats []SeatRef `json:"seats"` // Seats to change
+}
+
+// PlanStatusRequest carries an optional note for a lifecycle transition.
+type PlanStatusRequest struct {
+	Note string `json:"note"` // Reason or remark recorded with the transition
 }
 
 // CreateExamRequest represents the request to create an exam.
 type CreateExamRequest struct {
-	Title     string    `json:"title"`     // Exam title
-	Date      time.Time `json:"date"`      // Exam date
-	Duration  int       `json:"duration"`  // Duration in minutes
-	Faculty   string    `json:"faculty"`   // Faculty
-	Algorithm string    `json:"algorithm"` // Preferred seating algorithm
+	Title     string    `json:"title"`      // Exam title
+	Date      time.Time `json:"date"`       // Exam date
+	Duration  int       `json:"duration"`   // Duration in minutes
+	Faculty   string    `json:"faculty"`    // Faculty
+	Algorithm string    `json:"algorithm"`  // Preferred seating algorithm
+	PaperSets int       `json:"paper_sets"` // Number of question-paper variants (0 or 1 for a single paper)
 }
 
 // CreateRoomRequest represents the request to create a room.
 type CreateRoomRequest struct {
-	Name     string `json:"name"`     // Room name
-	Capacity int    `json:"capacity"` // Total capacity
-	Rows     int    `json:"rows"`     // Number of rows
-	Columns  int    `json:"columns"`  // Number of columns
-	Building string `json:"building"` // Building name
+	Name         string       `json:"name"`          // Room name
+	Capacity     int          `json:"capacity"`      // Ignored: capacity is derived from the layout
+	Rows         int          `json:"rows"`          // Number of rows
+	Columns      int          `json:"columns"`       // Number of columns
+	Building     string       `json:"building"`      // Building name
+	Layout       []LayoutCell `json:"layout"`        // Optional non-usable or accessible cells
+	GroundFloor  bool         `json:"ground_floor"`  // Reachable without stairs
+	SeparateRoom bool         `json:"separate_room"` // Reserved for students who sit apart
+}
+
+// RoomLayoutRequest represents the request to replace or patch a room layout.
+type RoomLayoutRequest struct {
+	Rows    int          `json:"rows"`    // New number of rows (0 keeps the current value)
+	Columns int          `json:"columns"` // New number of columns (0 keeps the current value)
+	Cells   []LayoutCell `json:"cells"`   // Cells that are not plain usable seats
+}
+
+// RoomLayoutResponse describes a room layout both sparsely and as a full grid.
+type RoomLayoutResponse struct {
+	RoomID   primitive.ObjectID `json:"room_id"`
+	Rows     int                `json:"rows"`
+	Columns  int                `json:"columns"`
+	Capacity int                `json:"capacity"`
+	Cells    []LayoutCell       `json:"cells"`
+	Grid     [][]string         `json:"grid"`
+}
+
+func newRoomLayoutResponse(room *Room) RoomLayoutResponse {
+	cells := room.Layout
+	if cells == nil {
+		cells = []LayoutCell{}
+	}
+	return RoomLayoutResponse{
+		RoomID:   room.ID,
+		Rows:     room.Rows,
+		Columns:  room.Columns,
+		Capacity: room.Capacity,
+		Cells:    cells,
+		Grid:     room.LayoutGrid(),
+	}
 }
 
 // CreateStudentRequest represents the request to create a student.
@@ -85,12 +177,89 @@ type AddRoomToExamRequest struct {
 	ExamID         string   `json:"exam_id"`          // Exam ID
 	RoomID         string   `json:"room_id"`          // Room ID
 	StudentListIDs []string `json:"student_list_ids"` // Student list IDs to assign to this room
+	Shared         bool     `json:"shared"`           // Allow concurrent exams booked as shared in the same room
+}
+
+// TimetableExamRequest names an exam to schedule and the student lists sitting it.
+type TimetableExamRequest struct {
+	ExamID         string   `json:"exam_id"`          // Exam ID
+	StudentListIDs []string `json:"student_list_ids"` // Student lists sitting the exam
+}
+
+// GenerateTimetableRequest represents the request to schedule exams into time slots and rooms.
+type GenerateTimetableRequest struct {
+	Exams      []TimetableExamRequest `json:"exams"`      // Exams to schedule
+	Slots      []time.Time            `json:"slots"`      // Start times exams may be put at
+	RoomIDs    []string               `json:"room_ids"`   // Rooms to book; empty means every room except separate rooms
+	Spacing    string                 `json:"spacing"`    // Spacing rule room capacities are counted under
+	Iterations int                    `json:"iterations"` // Improvement passes of the solver (default 50)
+	Apply      bool                   `json:"apply"`      // Write exam dates and draft room bookings; otherwise only preview
+}
+
+// BlockRoomRequest represents the request to take a room out of use for a period.
+type BlockRoomRequest struct {
+	Start  time.Time `json:"start"`  // Start of the block
+	End    time.Time `json:"end"`    // End of the block
+	Reason string    `json:"reason"` // Maintenance, event, ...
+}
+
+// examWithClashes is an exam together with the student clashes it takes part in.
+type examWithClashes struct {
+	*Exam
+	Clashes []StudentClash `json:"clashes,omitempty"`
+}
+
+// examRoomWithClashes is a room assignment together with the student clashes of its exam.
+type examRoomWithClashes struct {
+	*ExamRoom
+	Clashes []StudentClash `json:"clashes,omitempty"`
+}
+
+// AutoAllocateRoomsRequest represents the request to choose an exam's rooms automatically.
+type AutoAllocateRoomsRequest struct {
+	StudentListIDs []string `json:"student_list_ids"` // Student lists sitting the exam
+	Spacing        string   `json:"spacing"`          // Spacing rule the rooms must hold the students under
+	Building       string   `json:"building"`         // Preferred building, used whenever it has enough room alone
+	Commit         bool     `json:"commit"`           // Create the exam rooms; otherwise only preview them
 }
 
 // AddInvigilatorToRoomRequest represents the request to add an invigilator to a room.
 type AddInvigilatorToRoomRequest struct {
 	ExamRoomID    string `json:"exam_room_id"`   // Exam room ID
 	InvigilatorID string `json:"invigilator_id"` // Invigilator ID
+	Role          string `json:"role"`           // Role in the room (chief, assistant, relief, floor_supervisor); assistant if empty
+	Override      bool   `json:"override"`       // Assign even if the invigilator declared unavailability
+}
+
+// SetInvigilatorRoleRequest represents a change of an assigned invigilator's role.
+type SetInvigilatorRoleRequest struct {
+	Role string `json:"role"` // chief, assistant, relief or floor_supervisor
+}
+
+// UnavailabilityRequest represents a period a member of staff cannot invigilate. Either
+// date (a whole day) or start and end are given.
+type UnavailabilityRequest struct {
+	Date   string    `json:"date"`   // Whole day, YYYY-MM-DD
+	Start  time.Time `json:"start"`  // Start of the period
+	End    time.Time `json:"end"`    // End of the period
+	Reason string    `json:"reason"` // Leave, training, ...
+}
+
+// ProposeDutySwapRequest represents an invigilator's offer to trade duties with another.
+type ProposeDutySwapRequest struct {
+	ExamRoomID            string `json:"exam_room_id"`             // Caller's duty to give up
+	CounterpartID         string `json:"counterpart_id"`           // Invigilator to swap with
+	CounterpartExamRoomID string `json:"counterpart_exam_room_id"` // Their duty to take over
+	Reason                string `json:"reason"`
+}
+
+// AutoAssignInvigilatorsRequest represents the request to assign invigilators automatically.
+type AutoAssignInvigilatorsRequest struct {
+	ExamIDs                []string   `json:"exam_ids"`                 // One exam, or the exams of one session
+	StudentsPerInvigilator int        `json:"students_per_invigilator"` // Students one invigilator covers (default 30)
+	AvoidOwnFaculty        bool       `json:"avoid_own_faculty"`        // Never assign staff to an exam of their own faculty
+	TermStart              *time.Time `json:"term_start"`               // Start of the period duty minutes are balanced over
+	TermEnd                *time.Time `json:"term_end"`                 // End of that period
 }
 
 // GenerateSeatingPlan allows admins to generate a new seating plan.
@@ -100,9 +269,22 @@ func (h *SeatingHandler) GenerateSeatingPlan(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
 	}
 
-	// Validate algorithm
-	if req.Algorithm != "parallel" && req.Algorithm != "simple" && req.Algorithm != "separated" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid algorithm. Must be 'parallel', 'simple', or 'separated'"})
+	// Validate algorithm against the registry
+	if !h.service.HasAlgorithm(req.Algorithm) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid algorithm. See /api/seating/algorithms for available options"})
+	}
+
+	if req.Mode != "" && req.Mode != GenerationModePerRoom && req.Mode != GenerationModePooled {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid mode. Must be 'per_room' or 'pooled'"})
+	}
+	if req.Distribution != "" && !IsValidDistribution(req.Distribution) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid distribution. Must be 'matrix', 'random', or 'parallel'"})
+	}
+	if !IsValidSpacing(req.Spacing) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid spacing. Must be 'none', 'every_other_column', or 'checkerboard'"})
+	}
+	if !IsValidGrouping(req.Grouping) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid grouping. Must be 'department_or_batch', 'department', 'batch', 'department_batch', 'course', or 'exam'"})
 	}
 
 	// Convert string IDs to ObjectIDs
@@ -111,14 +293,408 @@ func (h *SeatingHandler) GenerateSeatingPlan(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
 	}
 
-	plans, err := h.service.GenerateSeatingPlan(context.Background(), examID, primitive.NilObjectID, req.InvigilatorEmail, req.Algorithm, nil)
+	plans, err := h.service.GenerateSeatingPlan(context.Background(), examID, GenerateOptions{
+		Algorithm:    req.Algorithm,
+		Parameters:   req.Parameters,
+		Mode:         req.Mode,
+		Distribution: req.Distribution,
+		Spacing:      req.Spacing,
+		Grouping:     req.Grouping,
+		Seed:         req.Seed,
+	})
 	if err != nil {
+		if errors.Is(err, ErrAccommodationUnmet) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusCreated, plans)
 }
 
+// GenerateSessionPlan seats the students of several concurrent exams in one plan,
+// separated by exam.
+func (h *SeatingHandler) GenerateSessionPlan(c echo.Context) error {
+	var req GenerateSessionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	if !h.service.HasAlgorithm(req.Algorithm) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid algorithm. See /api/seating/algorithms for available options"})
+	}
+	if req.Mode != "" && req.Mode != GenerationModePerRoom && req.Mode != GenerationModePooled {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid mode. Must be 'per_room' or 'pooled'"})
+	}
+	if req.Distribution != "" && !IsValidDistribution(req.Distribution) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid distribution. Must be 'matrix', 'random', or 'parallel'"})
+	}
+	if !IsValidSpacing(req.Spacing) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid spacing. Must be 'none', 'every_other_column', or 'checkerboard'"})
+	}
+	examIDs := make([]primitive.ObjectID, 0, len(req.ExamIDs))
+	for _, id := range req.ExamIDs {
+		examID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
+		}
+		examIDs = append(examIDs, examID)
+	}
+
+	plan, err := h.service.GenerateSessionPlan(c.Request().Context(), examIDs, GenerateOptions{
+		Algorithm:    req.Algorithm,
+		Parameters:   req.Parameters,
+		Mode:         req.Mode,
+		Distribution: req.Distribution,
+		Spacing:      req.Spacing,
+		Seed:         req.Seed,
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidSession) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if errors.Is(err, ErrAccommodationUnmet) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, plan)
+}
+
+// GetSessionExamView returns the part of a plan that concerns one of its exams.
+func (h *SeatingHandler) GetSessionExamView(c echo.Context) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	examID, err := primitive.ObjectIDFromHex(c.Param("examId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
+	}
+	plan, err := h.service.GetSessionExamView(c.Request().Context(), planID, examID)
+	if err != nil {
+		switch err.Error() {
+		case "seating plan not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Seating plan not found"})
+		case "exam is not part of this plan":
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Exam is not part of this plan"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch seating plan"})
+	}
+	return c.JSON(http.StatusOK, plan)
+}
+
+// RegenerateSeatingPlan reproduces a plan from its recorded seed and options.
+func (h *SeatingHandler) RegenerateSeatingPlan(c echo.Context) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	plan, identical, err := h.service.RegenerateSeatingPlan(c.Request().Context(), planID)
+	if err != nil {
+		if err.Error() == "seating plan not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Seating plan not found"})
+		}
+		if errors.Is(err, ErrAccommodationUnmet) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"plan":        plan,
+		"original_id": planID,
+		"identical":   identical,
+	})
+}
+
+// GenerateCandidatePlans generates several candidate plans for an exam and returns them ranked.
+func (h *SeatingHandler) GenerateCandidatePlans(c echo.Context) error {
+	var req GenerateCandidatesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	if len(req.Algorithms) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one algorithm is required"})
+	}
+	for _, name := range req.Algorithms {
+		if !h.service.HasAlgorithm(name) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid algorithm " + name + ". See /api/seating/algorithms for available options"})
+		}
+	}
+	if req.Mode != "" && req.Mode != GenerationModePerRoom && req.Mode != GenerationModePooled {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid mode. Must be 'per_room' or 'pooled'"})
+	}
+	if req.Distribution != "" && !IsValidDistribution(req.Distribution) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid distribution. Must be 'matrix', 'random', or 'parallel'"})
+	}
+	if !IsValidSpacing(req.Spacing) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid spacing. Must be 'none', 'every_other_column', or 'checkerboard'"})
+	}
+	if !IsValidGrouping(req.Grouping) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid grouping. Must be 'department_or_batch', 'department', 'batch', 'department_batch', 'course', or 'exam'"})
+	}
+	examID, err := primitive.ObjectIDFromHex(req.ExamID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
+	}
+
+	batch, err := h.service.GenerateCandidatePlans(c.Request().Context(), examID, CandidateOptions{
+		Algorithms:   req.Algorithms,
+		Seeds:        req.Seeds,
+		SeedCount:    req.SeedCount,
+		Parameters:   req.Parameters,
+		Mode:         req.Mode,
+		Distribution: req.Distribution,
+		Spacing:      req.Spacing,
+		Grouping:     req.Grouping,
+	})
+	if err != nil {
+		if errors.Is(err, ErrAccommodationUnmet) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, batch)
+}
+
+// GetCandidatePlans returns the ranked candidates of a candidate group.
+func (h *SeatingHandler) GetCandidatePlans(c echo.Context) error {
+	group, err := primitive.ObjectIDFromHex(c.Param("group"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid candidate group ID"})
+	}
+	batch, err := h.service.GetCandidatePlans(c.Request().Context(), group)
+	if err != nil {
+		if err.Error() == "candidate group not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Candidate group not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch candidates"})
+	}
+	return c.JSON(http.StatusOK, batch)
+}
+
+// PromoteCandidatePlan keeps one candidate plan and discards the rest of its group.
+func (h *SeatingHandler) PromoteCandidatePlan(c echo.Context) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	plan, discarded, err := h.service.PromoteCandidatePlan(c.Request().Context(), planID)
+	if err != nil {
+		switch err.Error() {
+		case "seating plan not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Seating plan not found"})
+		case "seating plan is not a candidate":
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Seating plan is not a candidate"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to promote candidate: " + err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"plan":      plan,
+		"discarded": discarded,
+	})
+}
+
+// seatEditResult writes the outcome of a manual seat edit.
+func seatEditResult(c echo.Context, plan *SeatingPlan, err error) error {
+	if err != nil {
+		if err.Error() == "seating plan not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Seating plan not found"})
+		}
+		if errors.Is(err, ErrInvalidSeatEdit) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if errors.Is(err, ErrPlanNotEditable) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update seating plan"})
+	}
+	return c.JSON(http.StatusOK, plan)
+}
+
+// SwapSeats exchanges the occupants of two seats in a plan.
+func (h *SeatingHandler) SwapSeats(c echo.Context) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	var req SwapSeatsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	plan, err := h.service.SwapSeats(c.Request().Context(), planID, req.A, req.B)
+	return seatEditResult(c, plan, err)
+}
+
+// MoveStudent moves a student to an empty seat in a plan.
+func (h *SeatingHandler) MoveStudent(c echo.Context) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	var req MoveStudentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if req.StudentID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Student ID is required"})
+	}
+	plan, err := h.service.MoveStudent(c.Request().Context(), planID, req.StudentID, req.To)
+	return seatEditResult(c, plan, err)
+}
+
+// LockSeats locks seats so that regeneration keeps them in place.
+func (h *SeatingHandler) LockSeats(c echo.Context) error {
+	return h.setSeatLocks(c, true)
+}
+
+// UnlockSeats releases locked seats.
+func (h *SeatingHandler) UnlockSeats(c echo.Context) error {
+	return h.setSeatLocks(c, false)
+}
+
+func (h *SeatingHandler) setSeatLocks(c echo.Context, locked bool) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	var req SeatLockRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if len(req.Seats) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one seat is required"})
+	}
+	plan, err := h.service.SetSeatLocks(c.Request().Context(), planID, req.Seats, locked)
+	return seatEditResult(c, plan, err)
+}
+
+// UpdateSeatingPlanStatus moves a plan along its lifecycle. The target state is part of
+// the path so that the Casbin policy decides which roles may request it.
+func (h *SeatingHandler) UpdateSeatingPlanStatus(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	var req PlanStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	plan, err := h.service.UpdateSeatingPlanStatus(c.Request().Context(), planID, c.Param("status"), claims.Email, claims.Role, req.Note)
+	if err != nil {
+		if err.Error() == "seating plan not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Seating plan not found"})
+		}
+		if errors.Is(err, ErrInvalidTransition) || errors.Is(err, ErrPlanNotEditable) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		if errors.Is(err, ErrUnderstaffed) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update plan status"})
+	}
+	return c.JSON(http.StatusOK, plan)
+}
+
+// DiffSeatingPlan lists the students whose seat or room differs from another plan of the same exam.
+func (h *SeatingHandler) DiffSeatingPlan(c echo.Context) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	againstID, err := primitive.ObjectIDFromHex(c.QueryParam("against"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid or missing 'against' plan ID"})
+	}
+	diff, err := h.service.DiffSeatingPlans(c.Request().Context(), planID, againstID)
+	if err != nil {
+		switch err.Error() {
+		case "seating plan not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Seating plan not found"})
+		case "plans belong to different exams":
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Plans belong to different exams"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to compare seating plans"})
+	}
+	return c.JSON(http.StatusOK, diff)
+}
+
+// GetExamPlanVersions lists the plan versions of an exam, oldest first.
+func (h *SeatingHandler) GetExamPlanVersions(c echo.Context) error {
+	examID, err := primitive.ObjectIDFromHex(c.Param("examId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
+	}
+	plans, err := h.service.GetExamPlanVersions(c.Request().Context(), examID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch plan versions"})
+	}
+	return c.JSON(http.StatusOK, plans)
+}
+
+// GetPlanDrift reports students added to or removed from the exam's lists since the plan was made.
+func (h *SeatingHandler) GetPlanDrift(c echo.Context) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	drift, err := h.service.GetPlanDrift(c.Request().Context(), planID)
+	if err != nil {
+		if err.Error() == "seating plan not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Seating plan not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check plan drift"})
+	}
+	return c.JSON(http.StatusOK, drift)
+}
+
+// ReseatSeatingPlan applies student list changes to a plan with minimal seat changes.
+func (h *SeatingHandler) ReseatSeatingPlan(c echo.Context) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	plan, diff, err := h.service.ReseatSeatingPlan(c.Request().Context(), planID)
+	if err != nil {
+		if err.Error() == "seating plan not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Seating plan not found"})
+		}
+		if err.Error() == "seating plan is in sync with student lists" || errors.Is(err, ErrPlanNotEditable) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to re-seat plan: " + err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"plan":        plan,
+		"original_id": planID,
+		"diff":        diff,
+	})
+}
+
+// GetSeatingPlanReport returns quality metrics for a seating plan.
+func (h *SeatingHandler) GetSeatingPlanReport(c echo.Context) error {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid plan ID"})
+	}
+	report, err := h.service.GetSeatingPlanReport(c.Request().Context(), planID)
+	if err != nil {
+		if err.Error() == "seating plan not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Seating plan not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to build plan report"})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// ListAlgorithms returns the registered seating algorithms and their tunable parameters.
+func (h *SeatingHandler) ListAlgorithms(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.service.ListAlgorithms())
+}
+
 // GetSeatingPlan retrieves a seating plan by ID.
 func (h *SeatingHandler) GetSeatingPlan(c echo.Context) error {
 	planID := c.Param("id")
@@ -169,6 +745,9 @@ func (h *SeatingHandler) CreateExam(c echo.Context) error {
 		log.Printf("[CreateExam] Failed to bind request: %v", err)
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request: " + err.Error()})
 	}
+	if req.PaperSets < 0 || req.PaperSets > MaxPaperSets {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("paper_sets must be between 0 and %d", MaxPaperSets)})
+	}
 
 	exam := &Exam{
 		ID:        primitive.NewObjectID(),
@@ -177,6 +756,7 @@ func (h *SeatingHandler) CreateExam(c echo.Context) error {
 		Duration:  req.Duration,
 		Faculty:   req.Faculty,
 		Algorithm: req.Algorithm,
+		PaperSets: req.PaperSets,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -188,7 +768,7 @@ func (h *SeatingHandler) CreateExam(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create exam: " + err.Error()})
 	}
 
-	return c.JSON(http.StatusCreated, exam)
+	return c.JSON(http.StatusCreated, examWithClashes{Exam: exam, Clashes: h.examClashes(c.Request().Context(), exam.ID)})
 }
 
 // CreateRoom allows admins to create a new room.
@@ -199,16 +779,21 @@ func (h *SeatingHandler) CreateRoom(c echo.Context) error {
 	}
 
 	room := &Room{
-		ID:       primitive.NewObjectID(),
-		Name:     req.Name,
-		Capacity: req.Capacity,
-		Rows:     req.Rows,
-		Columns:  req.Columns,
-		Building: req.Building,
+		ID:           primitive.NewObjectID(),
+		Name:         req.Name,
+		Rows:         req.Rows,
+		Columns:      req.Columns,
+		Building:     req.Building,
+		Layout:       req.Layout,
+		GroundFloor:  req.GroundFloor,
+		SeparateRoom: req.SeparateRoom,
 	}
 
-	err := h.service.repo.CreateRoom(context.Background(), room)
+	err := h.service.CreateRoom(context.Background(), room)
 	if err != nil {
+		if errors.Is(err, ErrInvalidLayout) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create room"})
 	}
 
@@ -262,6 +847,7 @@ func (h *SeatingHandler) UploadStudentList(c echo.Context) error {
 	var req struct {
 		Department string    `json:"department"`
 		Batch      string    `json:"batch"`
+		Course     string    `json:"course"` // Optional course or paper, used by course grouping
 		Faculty    string    `json:"faculty"`
 		Students   []Student `json:"students"`
 	}
@@ -271,6 +857,9 @@ func (h *SeatingHandler) UploadStudentList(c echo.Context) error {
 	if req.Department == "" || req.Batch == "" || req.Faculty == "" || len(req.Students) == 0 {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing required fields"})
 	}
+	if err := ValidateAccommodations(req.Students); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	// Robustly extract email from JWT claims (map or struct)
 	user := c.Get("user")
 	var uploadedBy string
@@ -298,12 +887,13 @@ func (h *SeatingHandler) UploadStudentList(c echo.Context) error {
 	if uploadedBy == "" {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Could not determine uploader from authentication context"})
 	}
-	// Only keep student_id and name for each student
+	// Only keep student_id, name and accommodations for each student
 	students := make([]Student, 0, len(req.Students))
 	for _, s := range req.Students {
 		students = append(students, Student{
-			StudentID: s.StudentID,
-			Name:      s.Name,
+			StudentID:      s.StudentID,
+			Name:           s.Name,
+			Accommodations: s.Accommodations,
 		})
 	}
 	// Auto-generate list name as Department/Batch
@@ -311,6 +901,7 @@ func (h *SeatingHandler) UploadStudentList(c echo.Context) error {
 	studentList := StudentList{
 		Department: req.Department,
 		Batch:      req.Batch,
+		Course:     req.Course,
 		Faculty:    req.Faculty,
 		Name:       listName,
 		Students:   students,
@@ -329,21 +920,100 @@ func (h *SeatingHandler) UploadStudentList(c echo.Context) error {
 	return c.JSON(http.StatusOK, studentList)
 }
 
-// AddRoomToExam allows admins to add a room to an exam.
-func (h *SeatingHandler) AddRoomToExam(c echo.Context) error {
-	log.Printf("[AddRoomToExam] Handler called")
-	var req AddRoomToExamRequest
-	if err := c.Bind(&req); err != nil {
-		log.Printf("[AddRoomToExam] Failed to bind request: %v", err)
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request: " + err.Error()})
+// ImportStudentList creates student lists from an uploaded CSV or XLSX file (multipart
+// field "file"). Form fields map columns (student_id_column, name_column,
+// department_column, batch_column, accommodations_column) and give defaults (faculty,
+// department, batch, course); dry_run=true only validates. Rows failing validation are
+// reported and nothing is saved.
+func (h *SeatingHandler) ImportStudentList(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
 	}
-
-	examID, err := primitive.ObjectIDFromHex(req.ExamID)
+	header, err := c.FormFile("file")
 	if err != nil {
-		log.Printf("[AddRoomToExam] Invalid exam ID: %v", err)
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID: " + err.Error()})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing file"})
 	}
-
+	if header.Size > MaxImportSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "File is too large"})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Could not read file"})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, MaxImportSize+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Could not read file"})
+	}
+	if len(data) > MaxImportSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "File is too large"})
+	}
+
+	dryRun := false
+	if value := c.FormValue("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid dry_run value"})
+		}
+	}
+	format := c.FormValue("format")
+	if format != "" && format != SpreadsheetCSV && format != SpreadsheetXLSX {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid format. Use csv or xlsx"})
+	}
+	opts := ImportOptions{
+		Format: format,
+		Sheet:  c.FormValue("sheet"),
+		Columns: ColumnMapping{
+			StudentID:      c.FormValue("student_id_column"),
+			Name:           c.FormValue("name_column"),
+			Department:     c.FormValue("department_column"),
+			Batch:          c.FormValue("batch_column"),
+			Accommodations: c.FormValue("accommodations_column"),
+		},
+		Department: c.FormValue("department"),
+		Batch:      c.FormValue("batch"),
+		Course:     c.FormValue("course"),
+		Faculty:    c.FormValue("faculty"),
+		IDPattern:  c.FormValue("id_pattern"),
+		UploadedBy: claims.Email,
+		DryRun:     dryRun,
+	}
+	if opts.Faculty == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing required fields"})
+	}
+
+	report, err := h.service.ImportStudentLists(c.Request().Context(), header.Filename, data, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrImportInvalid):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{"error": err.Error(), "report": report})
+		case errors.Is(err, ErrInvalidSpreadsheet):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		log.Printf("[ImportStudentList] Failed to import: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save student list"})
+	}
+	if report.Committed {
+		return c.JSON(http.StatusCreated, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// AddRoomToExam allows admins to add a room to an exam.
+func (h *SeatingHandler) AddRoomToExam(c echo.Context) error {
+	log.Printf("[AddRoomToExam] Handler called")
+	var req AddRoomToExamRequest
+	if err := c.Bind(&req); err != nil {
+		log.Printf("[AddRoomToExam] Failed to bind request: %v", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request: " + err.Error()})
+	}
+
+	examID, err := primitive.ObjectIDFromHex(req.ExamID)
+	if err != nil {
+		log.Printf("[AddRoomToExam] Invalid exam ID: %v", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID: " + err.Error()})
+	}
+
 	roomID, err := primitive.ObjectIDFromHex(req.RoomID)
 	if err != nil {
 		log.Printf("[AddRoomToExam] Invalid room ID: %v", err)
@@ -367,17 +1037,79 @@ func (h *SeatingHandler) AddRoomToExam(c echo.Context) error {
 		RoomID:         roomID,
 		StudentListIDs: studentListObjIDs,
 		Invigilators:   []primitive.ObjectID{},
+		Shared:         req.Shared,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
 
-	err = h.service.repo.CreateExamRoom(context.Background(), examRoom)
+	err = h.service.AddRoomToExam(c.Request().Context(), examRoom)
 	if err != nil {
 		log.Printf("[AddRoomToExam] Failed to add room to exam: %v", err)
+		if errors.Is(err, ErrRoomDoubleBooked) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		switch err.Error() {
+		case "exam not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam not found"})
+		case "room not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Room not found"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add room to exam: " + err.Error()})
 	}
 
-	return c.JSON(http.StatusCreated, examRoom)
+	return c.JSON(http.StatusCreated, examRoomWithClashes{ExamRoom: examRoom, Clashes: h.examClashes(c.Request().Context(), examID)})
+}
+
+// AutoAllocateRooms chooses the rooms for an exam, previewing them unless asked to commit.
+func (h *SeatingHandler) AutoAllocateRooms(c echo.Context) error {
+	examID, err := primitive.ObjectIDFromHex(c.Param("examId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
+	}
+	var req AutoAllocateRoomsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	if len(req.StudentListIDs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one student list is required"})
+	}
+	if !IsValidSpacing(req.Spacing) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid spacing. Must be 'none', 'every_other_column', or 'checkerboard'"})
+	}
+	listIDs := make([]primitive.ObjectID, 0, len(req.StudentListIDs))
+	for _, id := range req.StudentListIDs {
+		listID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid student list ID"})
+		}
+		listIDs = append(listIDs, listID)
+	}
+
+	allocation, err := h.service.AllocateExamRooms(c.Request().Context(), examID, AllocationOptions{
+		StudentListIDs: listIDs,
+		Spacing:        req.Spacing,
+		Building:       req.Building,
+		Commit:         req.Commit,
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotEnoughRooms) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		switch err.Error() {
+		case "exam not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam not found"})
+		case "student list not found":
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Student list not found"})
+		case "exam already has rooms assigned":
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Exam already has rooms assigned; clear them first"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to allocate rooms"})
+	}
+	if allocation.Committed {
+		allocation.Clashes = h.examClashes(c.Request().Context(), examID)
+		return c.JSON(http.StatusCreated, allocation)
+	}
+	return c.JSON(http.StatusOK, allocation)
 }
 
 // AddInvigilatorToRoom allows admins to add an invigilator to a room.
@@ -405,42 +1137,433 @@ func (h *SeatingHandler) AddInvigilatorToRoom(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid invigilator ID"})
 	}
 
-	// Check if invigilator is already assigned to another room in the same exam
-	examRoom, err := h.service.repo.FindExamRoomByID(context.Background(), examRoomID)
+	if req.Role == "" {
+		req.Role = InvigilatorRoleAssistant
+	}
+
+	// Duplicates, a second chief, other rooms of the exam and declared unavailability
+	// are checked by the service
+	log.Printf("[AddInvigilatorToRoom] Assigning invigilator %s to exam room %s as %s", invigilatorID.Hex(), examRoomID.Hex(), req.Role)
+	warning, err := h.service.AssignInvigilator(c.Request().Context(), examRoomID, invigilatorID, req.Role, req.Override)
 	if err != nil {
-		log.Printf("[AddInvigilatorToRoom] Failed to find exam room: %v", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to find exam room"})
+		if errors.Is(err, ErrInvalidAssignment) || errors.Is(err, ErrInvigilatorUnavailable) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		if err.Error() == "exam room not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam room not found"})
+		}
+		log.Printf("[AddInvigilatorToRoom] Failed to add invigilator: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add invigilator to room"})
 	}
-	if examRoom == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam room not found"})
+
+	if warning != "" {
+		return c.JSON(http.StatusOK, map[string]string{"message": "Invigilator added to room successfully", "warning": warning})
 	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Invigilator added to room successfully"})
+}
 
-	// Get all exam rooms for this exam
-	examRooms, err := h.service.repo.GetExamRooms(context.Background(), examRoom.ExamID)
+// SetInvigilatorRole changes the role of an invigilator already assigned to an exam room.
+func (h *SeatingHandler) SetInvigilatorRole(c echo.Context) error {
+	examRoomID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam room ID"})
+	}
+	invigilatorID, err := primitive.ObjectIDFromHex(c.Param("invigilatorId"))
 	if err != nil {
-		log.Printf("[AddInvigilatorToRoom] Failed to get exam rooms: %v", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get exam rooms"})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid invigilator ID"})
 	}
+	var req SetInvigilatorRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if err := h.service.SetInvigilatorRole(c.Request().Context(), examRoomID, invigilatorID, req.Role); err != nil {
+		if errors.Is(err, ErrInvalidAssignment) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		switch err.Error() {
+		case "exam room not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam room not found"})
+		case "invigilator not assigned to this room":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Invigilator is not assigned to this room"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update invigilator role"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Invigilator role updated successfully"})
+}
 
-	// Check if invigilator is already assigned to any other room in this exam
-	for _, er := range examRooms {
-		if er.ID != examRoomID { // Skip current room
-			for _, existingInvID := range er.Invigilators {
-				if existingInvID == invigilatorID {
-					return c.JSON(http.StatusConflict, map[string]string{"error": "Invigilator is already assigned to another room in this exam"})
-				}
-			}
+// GetExamStaffing reports, per room of an exam, the invigilators by role against the
+// minimum staffing for the room's size.
+func (h *SeatingHandler) GetExamStaffing(c echo.Context) error {
+	examID, err := primitive.ObjectIDFromHex(c.Param("examId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
+	}
+	report, err := h.service.GetExamStaffing(c.Request().Context(), examID)
+	if err != nil {
+		if err.Error() == "exam not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch staffing"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"rules": DefaultStaffingRules, "rooms": report})
+}
+
+// AutoAssignInvigilators tops up the invigilators of an exam's rooms, or a session's.
+func (h *SeatingHandler) AutoAssignInvigilators(c echo.Context) error {
+	var req AutoAssignInvigilatorsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	if len(req.ExamIDs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one exam is required"})
+	}
+	if req.StudentsPerInvigilator < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "students_per_invigilator must not be negative"})
+	}
+	opts := InvigilationOptions{
+		StudentsPerInvigilator: req.StudentsPerInvigilator,
+		AvoidOwnFaculty:        req.AvoidOwnFaculty,
+		TermStart:              req.TermStart,
+		TermEnd:                req.TermEnd,
+	}
+	for _, id := range req.ExamIDs {
+		examID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
 		}
+		opts.ExamIDs = append(opts.ExamIDs, examID)
 	}
 
-	log.Printf("[AddInvigilatorToRoom] Assigning invigilator %s to exam room %s", invigilatorID.Hex(), examRoomID.Hex())
-	err = h.service.repo.AddInvigilatorToRoom(context.Background(), examRoomID, invigilatorID)
+	result, err := h.service.AutoAssignInvigilators(c.Request().Context(), opts)
 	if err != nil {
-		log.Printf("[AddInvigilatorToRoom] Failed to add invigilator: %v", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add invigilator to room"})
+		if errors.Is(err, ErrInvalidSession) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if errors.Is(err, ErrNotEnoughInvigilators) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		switch err.Error() {
+		case "exam not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam not found"})
+		case "no rooms assigned to this exam":
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "No rooms assigned to this exam"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to assign invigilators"})
 	}
+	return c.JSON(http.StatusOK, result)
+}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "Invigilator added to room successfully"})
+// DeclareUnavailability records a period the calling member of staff cannot invigilate.
+func (h *SeatingHandler) DeclareUnavailability(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	var req UnavailabilityRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if req.Date != "" {
+		day, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid date. Use YYYY-MM-DD"})
+		}
+		req.Start, req.End = day, day.AddDate(0, 0, 1)
+	}
+	entry := &Unavailability{
+		ID:        primitive.NewObjectID(),
+		Start:     req.Start,
+		End:       req.End,
+		Reason:    req.Reason,
+		CreatedAt: time.Now(),
+	}
+	if err := h.service.DeclareUnavailability(c.Request().Context(), claims.Email, entry); err != nil {
+		switch err.Error() {
+		case "invigilator not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Invigilator not found"})
+		case "unavailability must end after it starts":
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unavailability must end after it starts"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to record unavailability"})
+	}
+	return c.JSON(http.StatusCreated, entry)
+}
+
+// GetMyUnavailability lists the calling member of staff's declared unavailability.
+func (h *SeatingHandler) GetMyUnavailability(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	entries, err := h.service.GetMyUnavailability(c.Request().Context(), claims.Email)
+	if err != nil {
+		if err.Error() == "invigilator not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Invigilator not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch unavailability"})
+	}
+	if entries == nil {
+		entries = []*Unavailability{}
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
+// DeleteMyUnavailability withdraws one of the calling member of staff's declarations.
+func (h *SeatingHandler) DeleteMyUnavailability(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid unavailability ID"})
+	}
+	if err := h.service.DeleteMyUnavailability(c.Request().Context(), claims.Email, id); err != nil {
+		switch err.Error() {
+		case "invigilator not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Invigilator not found"})
+		case "unavailability not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Unavailability not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete unavailability"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Unavailability deleted successfully"})
+}
+
+// ListUnavailability shows admins the declared unavailability of all staff, optionally
+// limited by the invigilator_id, from and to query parameters.
+func (h *SeatingHandler) ListUnavailability(c echo.Context) error {
+	var invigilatorID *primitive.ObjectID
+	if value := c.QueryParam("invigilator_id"); value != "" {
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid invigilator ID"})
+		}
+		invigilatorID = &id
+	}
+	from, err := parseCalendarTime(c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from' time"})
+	}
+	to, err := parseCalendarTime(c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to' time"})
+	}
+	entries, err := h.service.ListUnavailability(c.Request().Context(), invigilatorID, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch unavailability"})
+	}
+	if entries == nil {
+		entries = []*Unavailability{}
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
+// GetMyDuties lists the calling member of staff's invigilation duties, optionally
+// limited by the from and to query parameters.
+func (h *SeatingHandler) GetMyDuties(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	from, err := parseCalendarTime(c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from' time"})
+	}
+	to, err := parseCalendarTime(c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to' time"})
+	}
+	duties, err := h.service.GetMyDuties(c.Request().Context(), claims.Email, from, to)
+	if err != nil {
+		if err.Error() == "invigilator not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Invigilator not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch duties"})
+	}
+	return c.JSON(http.StatusOK, duties)
+}
+
+// GetDutyRoster returns every invigilation duty of a term given by the from and to
+// query parameters, as JSON or, with format=csv, as a CSV download.
+func (h *SeatingHandler) GetDutyRoster(c echo.Context) error {
+	from, err := parseCalendarTime(c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from' time"})
+	}
+	to, err := parseCalendarTime(c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to' time"})
+	}
+	duties, err := h.service.DutyRoster(c.Request().Context(), from, to)
+	if err != nil {
+		log.Printf("[GetDutyRoster] Failed to build roster: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to build duty roster"})
+	}
+	switch c.QueryParam("format") {
+	case "", "json":
+		return c.JSON(http.StatusOK, duties)
+	case "csv":
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="duty-roster.csv"`)
+		c.Response().WriteHeader(http.StatusOK)
+		return WriteDutyRosterCSV(c.Response(), duties)
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid format. Use json or csv"})
+	}
+}
+
+// dutySwapError maps duty swap service errors to responses.
+func dutySwapError(c echo.Context, err error, action string) error {
+	switch {
+	case errors.Is(err, ErrInvalidSwap):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrInvigilatorUnavailable):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	switch err.Error() {
+	case "invigilator not found":
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Invigilator not found"})
+	case "duty swap not found":
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Duty swap not found"})
+	case "exam room not found":
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam room not found"})
+	case "exam not found":
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam not found"})
+	}
+	log.Printf("[DutySwap] Failed to %s: %v", action, err)
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to " + action})
+}
+
+// ProposeDutySwap lets the calling invigilator offer one of their duties in exchange
+// for another invigilator's duty.
+func (h *SeatingHandler) ProposeDutySwap(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	var req ProposeDutySwapRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	examRoomID, err := primitive.ObjectIDFromHex(req.ExamRoomID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam room ID"})
+	}
+	counterpartID, err := primitive.ObjectIDFromHex(req.CounterpartID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid counterpart ID"})
+	}
+	counterpartExamRoomID, err := primitive.ObjectIDFromHex(req.CounterpartExamRoomID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid counterpart exam room ID"})
+	}
+	now := time.Now()
+	swap := &DutySwap{
+		ID:                    primitive.NewObjectID(),
+		RequesterExamRoomID:   examRoomID,
+		CounterpartID:         counterpartID,
+		CounterpartExamRoomID: counterpartExamRoomID,
+		Reason:                req.Reason,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	if err := h.service.ProposeDutySwap(c.Request().Context(), claims.Email, swap); err != nil {
+		return dutySwapError(c, err, "propose duty swap")
+	}
+	return c.JSON(http.StatusCreated, swap)
+}
+
+// GetMyDutySwaps lists the swaps the calling invigilator is part of, optionally
+// filtered by the status query parameter.
+func (h *SeatingHandler) GetMyDutySwaps(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	swaps, err := h.service.GetMyDutySwaps(c.Request().Context(), claims.Email, c.QueryParam("status"))
+	if err != nil {
+		return dutySwapError(c, err, "fetch duty swaps")
+	}
+	if swaps == nil {
+		swaps = []*DutySwap{}
+	}
+	return c.JSON(http.StatusOK, swaps)
+}
+
+// RespondToDutySwap lets the counterpart of a swap accept or decline it, depending on
+// the action path parameter.
+func (h *SeatingHandler) RespondToDutySwap(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid duty swap ID"})
+	}
+	var swap *DutySwap
+	switch c.Param("action") {
+	case "accept":
+		swap, err = h.service.RespondToDutySwap(c.Request().Context(), claims.Email, id, true)
+	case "decline":
+		swap, err = h.service.RespondToDutySwap(c.Request().Context(), claims.Email, id, false)
+	case "cancel":
+		swap, err = h.service.CancelDutySwap(c.Request().Context(), claims.Email, id)
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid action. Use accept, decline or cancel"})
+	}
+	if err != nil {
+		return dutySwapError(c, err, "update duty swap")
+	}
+	return c.JSON(http.StatusOK, swap)
+}
+
+// ListDutySwaps shows admins all swaps, optionally filtered by the invigilator_id and
+// status query parameters.
+func (h *SeatingHandler) ListDutySwaps(c echo.Context) error {
+	var invigilatorID *primitive.ObjectID
+	if value := c.QueryParam("invigilator_id"); value != "" {
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid invigilator ID"})
+		}
+		invigilatorID = &id
+	}
+	swaps, err := h.service.ListDutySwaps(c.Request().Context(), invigilatorID, c.QueryParam("status"))
+	if err != nil {
+		return dutySwapError(c, err, "fetch duty swaps")
+	}
+	if swaps == nil {
+		swaps = []*DutySwap{}
+	}
+	return c.JSON(http.StatusOK, swaps)
+}
+
+// DecideDutySwap lets an admin approve or reject a swap, depending on the action path
+// parameter. Approval exchanges the invigilators between the two rooms.
+func (h *SeatingHandler) DecideDutySwap(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid duty swap ID"})
+	}
+	var approve bool
+	switch c.Param("action") {
+	case "approve":
+		approve = true
+	case "reject":
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid action. Use approve or reject"})
+	}
+	swap, err := h.service.DecideDutySwap(c.Request().Context(), id, approve, claims.Email)
+	if err != nil {
+		return dutySwapError(c, err, "decide duty swap")
+	}
+	return c.JSON(http.StatusOK, swap)
 }
 
 // DeleteExam allows admins to delete an exam by ID.
@@ -481,6 +1604,9 @@ func (h *SeatingHandler) UpdateExam(c echo.Context) error {
 		log.Printf("[UpdateExam] Failed to bind request: %v", err)
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request: " + err.Error()})
 	}
+	if req.PaperSets < 0 || req.PaperSets > MaxPaperSets {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("paper_sets must be between 0 and %d", MaxPaperSets)})
+	}
 
 	exam := &Exam{
 		ID:        id,
@@ -489,16 +1615,20 @@ func (h *SeatingHandler) UpdateExam(c echo.Context) error {
 		Duration:  req.Duration,
 		Faculty:   req.Faculty,
 		Algorithm: req.Algorithm,
+		PaperSets: req.PaperSets,
 		UpdatedAt: time.Now(),
 	}
 
-	err = h.service.repo.UpdateExam(context.Background(), exam)
+	err = h.service.UpdateExam(c.Request().Context(), exam)
 	if err != nil {
 		log.Printf("[UpdateExam] Failed to update exam: %v", err)
+		if errors.Is(err, ErrRoomDoubleBooked) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update exam: " + err.Error()})
 	}
 
-	return c.JSON(http.StatusOK, exam)
+	return c.JSON(http.StatusOK, examWithClashes{Exam: exam, Clashes: h.examClashes(c.Request().Context(), exam.ID)})
 }
 
 // UpdateRoom allows admins to update a room by ID.
@@ -522,11 +1652,267 @@ func (h *SeatingHandler) UpdateRoom(c echo.Context) error {
 		if err.Error() == "room not found" {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "Room not found"})
 		}
+		if errors.Is(err, ErrInvalidLayout) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update room"})
 	}
 	return c.JSON(http.StatusOK, map[string]string{"message": "Room updated successfully"})
 }
 
+// examClashes runs the clash detector for an exam after it changed. Clashes are reported,
+// not enforced, so a failing check only gets logged.
+func (h *SeatingHandler) examClashes(ctx context.Context, examID primitive.ObjectID) []StudentClash {
+	report, err := h.service.DetectClashes(ctx, &examID)
+	if err != nil {
+		log.Printf("[ClashCheck] Failed to check exam %s for clashes: %v", examID.Hex(), err)
+		return nil
+	}
+	return report.Clashes
+}
+
+// GenerateTimetable schedules exams into time slots and rooms, previewing the timetable
+// unless asked to apply it.
+func (h *SeatingHandler) GenerateTimetable(c echo.Context) error {
+	var req GenerateTimetableRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	opts := TimetableOptions{Slots: req.Slots, Spacing: req.Spacing, Iterations: req.Iterations, Apply: req.Apply}
+	for _, exam := range req.Exams {
+		examID, err := primitive.ObjectIDFromHex(exam.ExamID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
+		}
+		input := TimetableExamInput{ExamID: examID}
+		for _, id := range exam.StudentListIDs {
+			listID, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid student list ID"})
+			}
+			input.StudentListIDs = append(input.StudentListIDs, listID)
+		}
+		opts.Exams = append(opts.Exams, input)
+	}
+	for _, id := range req.RoomIDs {
+		roomID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+		}
+		opts.RoomIDs = append(opts.RoomIDs, roomID)
+	}
+
+	timetable, err := h.service.GenerateTimetable(c.Request().Context(), opts)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTimetable) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if errors.Is(err, ErrTimetableIncomplete) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{"error": err.Error(), "timetable": timetable})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate timetable"})
+	}
+	if timetable.Applied {
+		return c.JSON(http.StatusCreated, timetable)
+	}
+	return c.JSON(http.StatusOK, timetable)
+}
+
+// ConfirmExamRooms confirms the draft room bookings the timetable generator made for an exam.
+func (h *SeatingHandler) ConfirmExamRooms(c echo.Context) error {
+	examID, err := primitive.ObjectIDFromHex(c.Param("examId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
+	}
+	confirmed, err := h.service.ConfirmExamRooms(c.Request().Context(), examID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to confirm room bookings"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"confirmed": confirmed})
+}
+
+// GetClashReport lists students listed for overlapping exams, across the timetable or,
+// with the exam_id query parameter, for one exam.
+func (h *SeatingHandler) GetClashReport(c echo.Context) error {
+	var examID *primitive.ObjectID
+	if value := c.QueryParam("exam_id"); value != "" {
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid exam ID"})
+		}
+		examID = &id
+	}
+	report, err := h.service.DetectClashes(c.Request().Context(), examID)
+	if err != nil {
+		if err.Error() == "exam not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Exam not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to check for clashes"})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetRoomCalendar lists a room's exam bookings and manual blocks, optionally limited to
+// the window given by the from and to query parameters (RFC 3339 or YYYY-MM-DD).
+func (h *SeatingHandler) GetRoomCalendar(c echo.Context) error {
+	roomID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+	}
+	from, err := parseCalendarTime(c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from' time"})
+	}
+	to, err := parseCalendarTime(c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to' time"})
+	}
+	calendar, err := h.service.GetRoomCalendar(c.Request().Context(), roomID, from, to)
+	if err != nil {
+		if err.Error() == "room not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Room not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch room calendar"})
+	}
+	return c.JSON(http.StatusOK, calendar)
+}
+
+// parseCalendarTime parses an optional calendar bound; empty means unbounded.
+func parseCalendarTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", value); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+// BlockRoom takes a room out of use for a period.
+func (h *SeatingHandler) BlockRoom(c echo.Context) error {
+	claims, ok := c.Get("user").(*auth.JWTClaims)
+	if !ok || claims == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+	roomID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+	}
+	var req BlockRoomRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	block := &RoomBlock{
+		ID:        primitive.NewObjectID(),
+		RoomID:    roomID,
+		Start:     req.Start,
+		End:       req.End,
+		Reason:    req.Reason,
+		CreatedBy: claims.Email,
+		CreatedAt: time.Now(),
+	}
+	if err := h.service.BlockRoom(c.Request().Context(), block); err != nil {
+		if errors.Is(err, ErrRoomDoubleBooked) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		switch err.Error() {
+		case "room not found":
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Room not found"})
+		case "block must end after it starts":
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Block must end after it starts"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to block room"})
+	}
+	return c.JSON(http.StatusCreated, block)
+}
+
+// UnblockRoom removes a manual block from a room.
+func (h *SeatingHandler) UnblockRoom(c echo.Context) error {
+	roomID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+	}
+	blockID, err := primitive.ObjectIDFromHex(c.Param("blockId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid block ID"})
+	}
+	if err := h.service.UnblockRoom(c.Request().Context(), roomID, blockID); err != nil {
+		if err.Error() == "room block not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Room block not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to remove room block"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Room block removed successfully"})
+}
+
+// roomLayoutResult writes the outcome of a layout operation.
+func roomLayoutResult(c echo.Context, room *Room, err error) error {
+	if err != nil {
+		if err.Error() == "room not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Room not found"})
+		}
+		if errors.Is(err, ErrInvalidLayout) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update room layout"})
+	}
+	return c.JSON(http.StatusOK, newRoomLayoutResponse(room))
+}
+
+// GetRoomLayout returns the layout of a room.
+func (h *SeatingHandler) GetRoomLayout(c echo.Context) error {
+	roomID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+	}
+	room, err := h.service.GetRoom(c.Request().Context(), roomID)
+	return roomLayoutResult(c, room, err)
+}
+
+// SetRoomLayout replaces the layout of a room, optionally resizing its grid.
+func (h *SeatingHandler) SetRoomLayout(c echo.Context) error {
+	roomID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+	}
+	var req RoomLayoutRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	room, err := h.service.SetRoomLayout(c.Request().Context(), roomID, req.Rows, req.Columns, req.Cells)
+	return roomLayoutResult(c, room, err)
+}
+
+// UpdateRoomLayoutCells changes the type of individual cells in a room layout.
+func (h *SeatingHandler) UpdateRoomLayoutCells(c echo.Context) error {
+	roomID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+	}
+	var req RoomLayoutRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if len(req.Cells) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one cell is required"})
+	}
+	room, err := h.service.UpdateRoomLayoutCells(c.Request().Context(), roomID, req.Cells)
+	return roomLayoutResult(c, room, err)
+}
+
+// ResetRoomLayout marks every cell of a room as a usable seat.
+func (h *SeatingHandler) ResetRoomLayout(c echo.Context) error {
+	roomID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+	}
+	room, err := h.service.ResetRoomLayout(c.Request().Context(), roomID)
+	return roomLayoutResult(c, room, err)
+}
+
 // GetAllExams retrieves all exams.
 func (h *SeatingHandler) GetAllExams(c echo.Context) error {
 	exams, err := h.service.repo.GetAllExams(context.Background())
@@ -623,6 +2009,9 @@ func (h *SeatingHandler) AddStudentToList(c echo.Context) error {
 	if student.StudentID == "" || student.Name == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Student ID and Name are required"})
 	}
+	if err := ValidateAccommodations([]Student{student}); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	if err := h.service.repo.AddStudentToList(c.Request().Context(), listID, student); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add student"})
 	}
@@ -644,6 +2033,9 @@ func (h *SeatingHandler) UpdateStudentInList(c echo.Context) error {
 	if student.StudentID == "" || student.Name == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Student ID and Name are required"})
 	}
+	if err := ValidateAccommodations([]Student{student}); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	if err := h.service.repo.UpdateStudentInList(c.Request().Context(), listID, studentID, student); err != nil {
 		if err.Error() == "student_id already exists in this list" {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
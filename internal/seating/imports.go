@@ -0,0 +1,258 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxImportSize is the largest spreadsheet accepted for a student list import, in bytes.
+const MaxImportSize = 10 << 20
+
+// DefaultStudentIDPattern accepts IDs of letters and digits, optionally split by
+// single -, /, _ or . separators.
+const DefaultStudentIDPattern = `^[A-Za-z0-9]+([-/_.][A-Za-z0-9]+)*$`
+
+// ErrInvalidSpreadsheet is wrapped when an uploaded file cannot be read or its columns
+// cannot be matched.
+var ErrInvalidSpreadsheet = errors.New("invalid spreadsheet")
+
+// ErrImportInvalid is wrapped when rows of an import fail validation; nothing is saved.
+var ErrImportInvalid = errors.New("student list import has invalid rows")
+
+// ColumnMapping names the spreadsheet header holding each student field. Headers match
+// ignoring case and surrounding spaces; empty entries fall back to common header names.
+type ColumnMapping struct {
+	StudentID      string `json:"student_id"`
+	Name           string `json:"name"`
+	Department     string `json:"department"`
+	Batch          string `json:"batch"`
+	Accommodations string `json:"accommodations"`
+}
+
+// importHeaders lists the header names tried for a field that is not mapped explicitly.
+var importHeaders = map[string][]string{
+	"student_id":     {"student_id", "student id", "studentid", "id", "roll no", "roll number", "registration no", "reg no", "cms id", "cms_id"},
+	"name":           {"name", "student name", "full name"},
+	"department":     {"department", "dept"},
+	"batch":          {"batch", "intake", "year"},
+	"accommodations": {"accommodations", "accommodation"},
+}
+
+// ImportOptions configures a student list import.
+type ImportOptions struct {
+	Format     string        // SpreadsheetCSV or SpreadsheetXLSX; empty detects it from the file
+	Sheet      string        // XLSX sheet name; empty means the first sheet
+	Columns    ColumnMapping // Header of each field
+	Department string        // Used for rows without a department column or value
+	Batch      string        // Used for rows without a batch column or value
+	Course     string        // Course or paper of every imported list
+	Faculty    string        // Faculty of every imported list
+	IDPattern  string        // Regular expression student IDs must match; empty means DefaultStudentIDPattern
+	UploadedBy string        // Email of the uploader
+	DryRun     bool          // Validate and report without saving
+}
+
+// ImportRowError is a problem with one spreadsheet row.
+type ImportRowError struct {
+	Row       int    `json:"row"` // Line or row number as shown in the spreadsheet
+	Column    string `json:"column,omitempty"`
+	StudentID string `json:"student_id,omitempty"`
+	Message   string `json:"message"`
+}
+
+// ImportReport describes what an import read and what it created.
+type ImportReport struct {
+	Format    string           `json:"format"`
+	Rows      int              `json:"rows"` // Data rows read, header excluded
+	Valid     int              `json:"valid"`
+	Errors    []ImportRowError `json:"errors"`
+	Lists     []*StudentList   `json:"lists"` // One per department and batch; created unless dry run or invalid
+	DryRun    bool             `json:"dry_run"`
+	Committed bool             `json:"committed"`
+}
+
+// importColumn finds the column of a field in the header row, or -1 when it is absent.
+// An explicit header that cannot be found is an error.
+func importColumn(header []string, field, mapped string) (int, error) {
+	names := importHeaders[field]
+	if mapped != "" {
+		names = []string{mapped}
+	}
+	for _, name := range names {
+		for i, cell := range header {
+			if strings.EqualFold(strings.TrimSpace(cell), strings.TrimSpace(name)) {
+				return i, nil
+			}
+		}
+	}
+	if mapped != "" {
+		return -1, fmt.Errorf("%w: column %q not found in header", ErrInvalidSpreadsheet, mapped)
+	}
+	return -1, nil
+}
+
+// importCell returns the trimmed cell of a row, or "" when the row is short or the
+// column is absent.
+func importCell(row spreadsheetRow, column int) string {
+	if column < 0 || column >= len(row.Cells) {
+		return ""
+	}
+	return strings.TrimSpace(row.Cells[column])
+}
+
+// ImportStudentLists reads students from a CSV or XLSX file and creates one student list
+// per department and batch found. Every row is validated first; if any row fails, the
+// report lists the problems and nothing is saved.
+func (s *SeatingService) ImportStudentLists(ctx context.Context, filename string, data []byte, opts ImportOptions) (*ImportReport, error) {
+	format := opts.Format
+	if format == "" {
+		var err error
+		if format, err = spreadsheetFormat(filename, data); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSpreadsheet, err)
+		}
+	}
+	rows, err := readSpreadsheet(format, data, opts.Sheet)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpreadsheet, err)
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("%w: expected a header row and at least one student", ErrInvalidSpreadsheet)
+	}
+	pattern := opts.IDPattern
+	if pattern == "" {
+		pattern = DefaultStudentIDPattern
+	}
+	idPattern, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid student ID pattern: %v", ErrInvalidSpreadsheet, err)
+	}
+
+	header := rows[0].Cells
+	columns := make(map[string]int)
+	for _, column := range []struct{ field, mapped string }{
+		{"student_id", opts.Columns.StudentID},
+		{"name", opts.Columns.Name},
+		{"department", opts.Columns.Department},
+		{"batch", opts.Columns.Batch},
+		{"accommodations", opts.Columns.Accommodations},
+	} {
+		if columns[column.field], err = importColumn(header, column.field, column.mapped); err != nil {
+			return nil, err
+		}
+	}
+	if columns["student_id"] < 0 || columns["name"] < 0 {
+		return nil, fmt.Errorf("%w: student ID and name columns are required; map them if the header uses other names", ErrInvalidSpreadsheet)
+	}
+	if columns["department"] < 0 && opts.Department == "" {
+		return nil, fmt.Errorf("%w: no department column; give a department for the whole file", ErrInvalidSpreadsheet)
+	}
+	if columns["batch"] < 0 && opts.Batch == "" {
+		return nil, fmt.Errorf("%w: no batch column; give a batch for the whole file", ErrInvalidSpreadsheet)
+	}
+
+	report := &ImportReport{Format: format, Rows: len(rows) - 1, Errors: []ImportRowError{}, Lists: []*StudentList{}, DryRun: opts.DryRun}
+	seen := make(map[string]int) // Student ID to the row it first appeared on
+	lists := make(map[string]*StudentList)
+	for _, row := range rows[1:] {
+		id := importCell(row, columns["student_id"])
+		name := importCell(row, columns["name"])
+		department := importCell(row, columns["department"])
+		if department == "" {
+			department = opts.Department
+		}
+		batch := importCell(row, columns["batch"])
+		if batch == "" {
+			batch = opts.Batch
+		}
+		fail := func(column, message string) {
+			report.Errors = append(report.Errors, ImportRowError{Row: row.Line, Column: column, StudentID: id, Message: message})
+		}
+
+		before := len(report.Errors)
+		switch {
+		case id == "":
+			fail("student_id", "student ID is blank")
+		case !idPattern.MatchString(id):
+			fail("student_id", fmt.Sprintf("student ID %q is malformed", id))
+		case seen[strings.ToUpper(id)] > 0:
+			fail("student_id", fmt.Sprintf("duplicate of row %d", seen[strings.ToUpper(id)]))
+		default:
+			seen[strings.ToUpper(id)] = row.Line
+		}
+		if name == "" {
+			fail("name", "name is blank")
+		}
+		if department == "" {
+			fail("department", "department is blank")
+		}
+		if batch == "" {
+			fail("batch", "batch is blank")
+		}
+		var accommodations []string
+		for _, value := range strings.FieldsFunc(importCell(row, columns["accommodations"]), func(r rune) bool { return r == ';' || r == ',' }) {
+			value = strings.ToLower(strings.TrimSpace(value))
+			if value == "" {
+				continue
+			}
+			if !IsValidAccommodation(value) {
+				fail("accommodations", fmt.Sprintf("unknown accommodation %q", value))
+				continue
+			}
+			accommodations = append(accommodations, value)
+		}
+		if len(report.Errors) > before {
+			continue
+		}
+
+		report.Valid++
+		key := department + "/" + batch
+		list, ok := lists[key]
+		if !ok {
+			list = &StudentList{
+				ID:         primitive.NewObjectID(),
+				Department: department,
+				Batch:      batch,
+				Course:     opts.Course,
+				Faculty:    opts.Faculty,
+				Name:       key,
+				UploadedBy: opts.UploadedBy,
+			}
+			lists[key] = list
+			report.Lists = append(report.Lists, list)
+		}
+		list.Students = append(list.Students, Student{StudentID: id, Name: name, Accommodations: accommodations})
+	}
+
+	if len(report.Errors) > 0 {
+		return report, fmt.Errorf("%w: %d of %d rows", ErrImportInvalid, report.Rows-report.Valid, report.Rows)
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+	for _, list := range report.Lists {
+		if err := s.repo.CreateStudentList(ctx, list); err != nil {
+			return nil, err
+		}
+		// Insert each student into the students collection if not already present
+		for i := range list.Students {
+			existing, err := s.repo.FindStudentByID(ctx, list.Students[i].StudentID)
+			if err != nil {
+				return nil, err
+			}
+			if existing == nil {
+				if err := s.repo.CreateStudent(ctx, &list.Students[i]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// Why: Staff were retyping registrar spreadsheets into JSON; importing them directly with a row-level report and a dry run catches bad IDs before they reach a seating plan.
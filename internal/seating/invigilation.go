@@ -0,0 +1,305 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultStudentsPerInvigilator is the ratio used when none is configured.
+const DefaultStudentsPerInvigilator = 30
+
+// ErrNotEnoughInvigilators is wrapped when too few staff are free to cover every room.
+var ErrNotEnoughInvigilators = errors.New("not enough invigilators available")
+
+// InvigilationOptions configures automatic invigilator assignment.
+type InvigilationOptions struct {
+	ExamIDs                []primitive.ObjectID // One exam, or the exams of one session
+	StudentsPerInvigilator int                  // Students one invigilator covers; 0 means the default
+	AvoidOwnFaculty        bool                 // Never let staff invigilate an exam of their own faculty
+	TermStart              *time.Time           // Duty minutes are balanced over exams starting in [TermStart, TermEnd); nil leaves a side open
+	TermEnd                *time.Time
+}
+
+// InvigilatorDuty is one invigilator newly assigned to a room.
+type InvigilatorDuty struct {
+	InvigilatorID primitive.ObjectID `json:"invigilator_id"`
+	Name          string             `json:"name"`
+	Email         string             `json:"email"`
+	Faculty       string             `json:"faculty"`
+	ExamRoomID    primitive.ObjectID `json:"exam_room_id"`
+	RoomID        primitive.ObjectID `json:"room_id"`
+	RoomName      string             `json:"room_name"`
+	Role          string             `json:"role"`         // Chief while the room has none, assistant otherwise
+	DutyMinutes   int                `json:"duty_minutes"` // Term total including this duty
+}
+
+// RoomInvigilation is the invigilator cover of one room after assignment.
+type RoomInvigilation struct {
+	ExamRoomID   primitive.ObjectID   `json:"exam_room_id"`
+	RoomID       primitive.ObjectID   `json:"room_id"`
+	RoomName     string               `json:"room_name"`
+	Students     int                  `json:"students"`
+	Required     int                  `json:"required"`
+	Invigilators []primitive.ObjectID `json:"invigilators"`
+}
+
+// InvigilationResult reports what automatic assignment did.
+type InvigilationResult struct {
+	ExamIDs  []primitive.ObjectID `json:"exam_ids"`
+	Assigned []InvigilatorDuty    `json:"assigned"`
+	Rooms    []RoomInvigilation   `json:"rooms"`
+}
+
+// inTerm reports whether an exam starts within the term window.
+func inTerm(exam *Exam, start, end *time.Time) bool {
+	if start != nil && exam.Date.Before(*start) {
+		return false
+	}
+	return end == nil || exam.Date.Before(*end)
+}
+
+// requiredInvigilators returns how many invigilators a room with the given number of
+// students needs: one per started group of perInvigilator students, and at least one.
+func requiredInvigilators(students, perInvigilator int) int {
+	required := (students + perInvigilator - 1) / perInvigilator
+	if required < 1 {
+		return 1
+	}
+	return required
+}
+
+// invigilationRoom gathers the assignments of one physical room within the exams being covered.
+type invigilationRoom struct {
+	examRooms []*ExamRoom
+	exams     []*Exam
+	students  int
+	required  int
+	staffed   int // Chiefs and assistants already assigned
+	chiefs    int
+	rule      StaffingRule
+	current   []primitive.ObjectID
+}
+
+// AutoAssignInvigilators tops up the invigilators of every room of an exam, or of all
+// exams in one session, to the students-per-invigilator ratio or the staffing rule for
+// the room's size, whichever asks for more. Staff already on duty at an overlapping exam
+// are never picked; among the rest those with the fewest duty minutes in the term go
+// first. Staff who declared unavailability are skipped. Nothing is saved unless every
+// room can be covered.
+func (s *SeatingService) AutoAssignInvigilators(ctx context.Context, opts InvigilationOptions) (*InvigilationResult, error) {
+	if len(opts.ExamIDs) == 0 {
+		return nil, errors.New("no exams given")
+	}
+	if opts.StudentsPerInvigilator <= 0 {
+		opts.StudentsPerInvigilator = DefaultStudentsPerInvigilator
+	}
+	first, err := s.repo.FindExamByID(ctx, opts.ExamIDs[0])
+	if err != nil {
+		return nil, err
+	}
+	if first == nil {
+		return nil, errors.New("exam not found")
+	}
+	examIDs, err := s.sessionExamIDs(ctx, first, opts.ExamIDs[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	allExams, err := s.repo.GetAllExams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	examsByID := make(map[primitive.ObjectID]*Exam, len(allExams))
+	for _, exam := range allExams {
+		examsByID[exam.ID] = exam
+	}
+	covered := make(map[primitive.ObjectID]bool, len(examIDs))
+	for _, id := range examIDs {
+		covered[id] = true
+	}
+
+	// Group the exams' room assignments per physical room
+	var rooms []*invigilationRoom
+	byRoom := make(map[primitive.ObjectID]*invigilationRoom)
+	for _, examID := range examIDs {
+		examRooms, err := s.repo.GetExamRooms(ctx, examID)
+		if err != nil {
+			return nil, err
+		}
+		for _, examRoom := range examRooms {
+			room, ok := byRoom[examRoom.RoomID]
+			if !ok {
+				room = &invigilationRoom{}
+				byRoom[examRoom.RoomID] = room
+				rooms = append(rooms, room)
+			}
+			room.examRooms = append(room.examRooms, examRoom)
+			room.exams = append(room.exams, examsByID[examID])
+			for _, id := range examRoom.Invigilators {
+				if !containsObjectID(room.current, id) {
+					room.current = append(room.current, id)
+				}
+			}
+		}
+	}
+	if len(rooms) == 0 {
+		return nil, errors.New("no rooms assigned to this exam")
+	}
+	for _, room := range rooms {
+		var listIDs []primitive.ObjectID
+		for _, examRoom := range room.examRooms {
+			listIDs = append(listIDs, examRoom.StudentListIDs...)
+		}
+		if len(listIDs) > 0 {
+			lists, err := s.repo.FindStudentListsByIDs(ctx, listIDs)
+			if err != nil {
+				return nil, err
+			}
+			room.students = len(studentsFromLists(lists))
+		}
+		staffing := staffRoom(room.examRooms, room.students)
+		room.rule = staffing.Required
+		room.required = max(requiredInvigilators(room.students, opts.StudentsPerInvigilator), room.rule.Chiefs+room.rule.Assistants)
+		room.staffed = staffing.Chiefs + staffing.Assistants
+		room.chiefs = staffing.Chiefs
+	}
+
+	// Term workload and clashes of every member of staff
+	allExamRooms, err := s.repo.GetAllExamRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dutyMinutes := make(map[primitive.ObjectID]int)
+	busy := make(map[primitive.ObjectID]bool)
+	onDuty := make(map[primitive.ObjectID]map[primitive.ObjectID]bool) // Exams each person already covers
+	for _, examRoom := range allExamRooms {
+		exam := examsByID[examRoom.ExamID]
+		if exam == nil {
+			continue
+		}
+		for _, id := range examRoom.Invigilators {
+			if onDuty[id] == nil {
+				onDuty[id] = make(map[primitive.ObjectID]bool)
+			}
+			if onDuty[id][exam.ID] {
+				continue
+			}
+			onDuty[id][exam.ID] = true
+			if inTerm(exam, opts.TermStart, opts.TermEnd) {
+				dutyMinutes[id] += exam.Duration
+			}
+			// Staff already in one of the covered exams keep to that room
+			if covered[exam.ID] {
+				busy[id] = true
+				continue
+			}
+			for _, examID := range examIDs {
+				if examsOverlap(exam, examsByID[examID]) {
+					busy[id] = true
+				}
+			}
+		}
+	}
+
+	// Declared leave rules staff out just like another duty
+	sessionExams := make([]*Exam, 0, len(examIDs))
+	for _, id := range examIDs {
+		sessionExams = append(sessionExams, examsByID[id])
+	}
+	unavailable, err := s.unavailableDuring(ctx, sessionExams)
+	if err != nil {
+		return nil, err
+	}
+	for id := range unavailable {
+		busy[id] = true
+	}
+
+	staff, err := s.repo.GetAllInvigilators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(staff, func(i, j int) bool { return staff[i].Name < staff[j].Name })
+
+	// Rooms needing the most cover pick first
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return rooms[i].required-rooms[i].staffed > rooms[j].required-rooms[j].staffed
+	})
+	result := &InvigilationResult{ExamIDs: examIDs, Assigned: []InvigilatorDuty{}, Rooms: []RoomInvigilation{}}
+	for _, room := range rooms {
+		examRoom := room.examRooms[0]
+		roomName := examRoom.RoomID.Hex()
+		if r, err := s.repo.FindRoomByID(ctx, examRoom.RoomID); err == nil && r != nil {
+			roomName = r.Name
+		}
+		minutes := 0
+		faculties := make(map[string]bool)
+		for _, exam := range room.exams {
+			if exam.Duration > minutes {
+				minutes = exam.Duration
+			}
+			if exam.Faculty != "" {
+				faculties[exam.Faculty] = true
+			}
+		}
+
+		var candidates []*User
+		for _, user := range staff {
+			if busy[user.ID] || containsObjectID(room.current, user.ID) {
+				continue
+			}
+			if opts.AvoidOwnFaculty && faculties[user.Faculty] {
+				continue
+			}
+			candidates = append(candidates, user)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return dutyMinutes[candidates[i].ID] < dutyMinutes[candidates[j].ID] })
+
+		need := room.required - room.staffed
+		if need > len(candidates) {
+			return nil, fmt.Errorf("%w: room %s needs %d more invigilators but only %d are free", ErrNotEnoughInvigilators, roomName, need, len(candidates))
+		}
+		for _, user := range candidates[:max(need, 0)] {
+			role := InvigilatorRoleAssistant
+			if room.chiefs < room.rule.Chiefs {
+				role = InvigilatorRoleChief
+				room.chiefs++
+			}
+			busy[user.ID] = true
+			dutyMinutes[user.ID] += minutes
+			room.current = append(room.current, user.ID)
+			result.Assigned = append(result.Assigned, InvigilatorDuty{
+				InvigilatorID: user.ID,
+				Name:          user.Name,
+				Email:         user.Email,
+				Faculty:       user.Faculty,
+				ExamRoomID:    examRoom.ID,
+				RoomID:        examRoom.RoomID,
+				RoomName:      roomName,
+				Role:          role,
+				DutyMinutes:   dutyMinutes[user.ID],
+			})
+		}
+		result.Rooms = append(result.Rooms, RoomInvigilation{
+			ExamRoomID:   examRoom.ID,
+			RoomID:       examRoom.RoomID,
+			RoomName:     roomName,
+			Students:     room.students,
+			Required:     room.required,
+			Invigilators: room.current,
+		})
+	}
+
+	for _, duty := range result.Assigned {
+		if err := s.repo.AddInvigilatorToRoom(ctx, duty.ExamRoomID, duty.InvigilatorID, duty.Role); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Why: Picking invigilators by hand overloads the same few people and double-books others; a ratio-driven top-up ordered by term duty minutes spreads the load and keeps clashes out by construction.
@@ -0,0 +1,205 @@
+package seating
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Cell types that can appear in a room layout. Cells not listed in Room.Layout are usable seats.
+const (
+	CellUsable          = "usable"           // Ordinary seat
+	CellBlocked         = "blocked"          // Pillar, broken desk, or outside a non-rectangular hall
+	CellAisle           = "aisle"            // Walkway, never seated
+	CellAccessible      = "accessible"       // Seat reachable by wheelchair users
+	CellInvigilatorDesk = "invigilator_desk" // Reserved for staff
+	CellSpacing         = "spacing"          // Left free by the spacing rule of a generation run; never stored on rooms
+	CellLocked          = "locked"           // Reserved for a locked or accommodated seat during a generation run; never stored on rooms
+)
+
+// Spacing rules that force seats to stay empty during generation.
+const (
+	SpacingNone             = "none"               // Use every seatable cell
+	SpacingEveryOtherColumn = "every_other_column" // Leave columns 2, 4, 6, ... empty
+	SpacingCheckerboard     = "checkerboard"       // Leave cells where row+column is odd empty
+)
+
+// IsValidSpacing reports whether name is a known spacing rule; empty means none.
+func IsValidSpacing(name string) bool {
+	switch name {
+	case "", SpacingNone, SpacingEveryOtherColumn, SpacingCheckerboard:
+		return true
+	}
+	return false
+}
+
+// ErrInvalidLayout is wrapped by every layout validation failure.
+var ErrInvalidLayout = errors.New("invalid room layout")
+
+// LayoutCell overrides the type of a single grid position in a room.
+type LayoutCell struct {
+	Row    int    `bson:"row" json:"row"`       // Row number (1-based)
+	Column int    `bson:"column" json:"column"` // Column number (1-based)
+	Type   string `bson:"type" json:"type"`     // One of the Cell* constants
+}
+
+// isValidCellType reports whether t is a known layout cell type.
+func isValidCellType(t string) bool {
+	switch t {
+	case CellUsable, CellBlocked, CellAisle, CellAccessible, CellInvigilatorDesk:
+		return true
+	}
+	return false
+}
+
+// isSeatable reports whether a student may be placed on a cell of the given type.
+func isSeatable(t string) bool {
+	return t == CellUsable || t == CellAccessible
+}
+
+// ValidateLayout checks grid dimensions and that every cell lies inside the grid,
+// has a known type and is listed only once.
+func ValidateLayout(rows, columns int, cells []LayoutCell) error {
+	if rows <= 0 || columns <= 0 {
+		return fmt.Errorf("%w: rows and columns must be positive", ErrInvalidLayout)
+	}
+	seen := make(map[[2]int]bool, len(cells))
+	for _, cell := range cells {
+		if cell.Row < 1 || cell.Row > rows || cell.Column < 1 || cell.Column > columns {
+			return fmt.Errorf("%w: cell (%d,%d) is outside the %dx%d grid", ErrInvalidLayout, cell.Row, cell.Column, rows, columns)
+		}
+		if !isValidCellType(cell.Type) {
+			return fmt.Errorf("%w: cell (%d,%d) has unknown type %q", ErrInvalidLayout, cell.Row, cell.Column, cell.Type)
+		}
+		key := [2]int{cell.Row, cell.Column}
+		if seen[key] {
+			return fmt.Errorf("%w: cell (%d,%d) is listed more than once", ErrInvalidLayout, cell.Row, cell.Column)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// normalizeLayout drops usable cells (the default) and anything outside the grid.
+func normalizeLayout(rows, columns int, cells []LayoutCell) []LayoutCell {
+	normalized := make([]LayoutCell, 0, len(cells))
+	for _, cell := range cells {
+		if cell.Type == CellUsable || cell.Row < 1 || cell.Row > rows || cell.Column < 1 || cell.Column > columns {
+			continue
+		}
+		normalized = append(normalized, cell)
+	}
+	return normalized
+}
+
+// cellTypes returns the type of every grid position in row-major order.
+func (r *Room) cellTypes() []string {
+	if r.Rows <= 0 || r.Columns <= 0 {
+		return nil
+	}
+	types := make([]string, r.Rows*r.Columns)
+	for i := range types {
+		types[i] = CellUsable
+	}
+	for _, cell := range r.Layout {
+		if cell.Row >= 1 && cell.Row <= r.Rows && cell.Column >= 1 && cell.Column <= r.Columns {
+			types[(cell.Row-1)*r.Columns+cell.Column-1] = cell.Type
+		}
+	}
+	return types
+}
+
+// seatableMask reports for each grid position (row-major) whether a student can sit there.
+func (r *Room) seatableMask() []bool {
+	types := r.cellTypes()
+	mask := make([]bool, len(types))
+	for i, t := range types {
+		mask[i] = isSeatable(t)
+	}
+	return mask
+}
+
+// SeatableCount returns the number of cells that can hold a student.
+func (r *Room) SeatableCount() int {
+	count := 0
+	for _, ok := range r.seatableMask() {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
+
+// LayoutGrid expands the sparse layout into a full rows x columns grid of cell types.
+func (r *Room) LayoutGrid() [][]string {
+	types := r.cellTypes()
+	if types == nil {
+		return [][]string{}
+	}
+	grid := make([][]string, r.Rows)
+	for i := range grid {
+		grid[i] = types[i*r.Columns : (i+1)*r.Columns]
+	}
+	return grid
+}
+
+// seatCellType returns the value stored on Seat.CellType; usable seats leave it blank.
+func seatCellType(t string) string {
+	if t == CellUsable {
+		return ""
+	}
+	return t
+}
+
+// applySpacing returns a copy of the room whose layout additionally marks the cells
+// excluded by the spacing rule, so capacity and every algorithm honour it.
+// Cells that are already unusable keep their own type.
+func applySpacing(room *Room, spacing string) *Room {
+	if spacing == "" || spacing == SpacingNone {
+		return room
+	}
+	spaced := *room
+	types := room.cellTypes()
+	spaced.Layout = append([]LayoutCell(nil), room.Layout...)
+	for i, t := range types {
+		row, col := i/room.Columns+1, i%room.Columns+1
+		excluded := false
+		switch spacing {
+		case SpacingEveryOtherColumn:
+			excluded = col%2 == 0
+		case SpacingCheckerboard:
+			excluded = (row+col)%2 == 1
+		}
+		if !excluded || !isSeatable(t) {
+			continue
+		}
+		if t == CellUsable {
+			spaced.Layout = append(spaced.Layout, LayoutCell{Row: row, Column: col, Type: CellSpacing})
+			continue
+		}
+		// Replace the existing override (e.g. accessible) for this cell
+		for k := range spaced.Layout {
+			if spaced.Layout[k].Row == row && spaced.Layout[k].Column == col {
+				spaced.Layout[k].Type = CellSpacing
+			}
+		}
+	}
+	spaced.Capacity = spaced.SeatableCount()
+	return &spaced
+}
+
+// emptySeats returns an unoccupied seat for every grid position, tagged with its cell type.
+func emptySeats(room *Room) []Seat {
+	types := room.cellTypes()
+	seats := make([]Seat, len(types))
+	for i, t := range types {
+		seats[i] = Seat{
+			Row:      i/room.Columns + 1,
+			Column:   i%room.Columns + 1,
+			IsEmpty:  true,
+			CellType: seatCellType(t),
+		}
+	}
+	return seats
+}
+
+// Why: Real halls have pillars, aisles and stepped rows; keeping a sparse per-cell override on the room lets algorithms skip unusable cells and lets capacity follow the actual floor plan.
@@ -0,0 +1,154 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Seating plan lifecycle states, in their normal order.
+const (
+	PlanStatusDraft     = "draft"     // Freshly generated or sent back for changes
+	PlanStatusReviewed  = "reviewed"  // Checked by staff, waiting for approval
+	PlanStatusApproved  = "approved"  // Signed off, ready to publish
+	PlanStatusPublished = "published" // Visible to students; seats can no longer change
+	PlanStatusArchived  = "archived"  // Exam is over; kept for the record
+)
+
+// planTransitions lists the states each state may move to. Who may request a given
+// target state is decided by the Casbin policy on POST /api/seating/plans/*/status/<state>.
+var planTransitions = map[string][]string{
+	PlanStatusDraft:     {PlanStatusReviewed},
+	PlanStatusReviewed:  {PlanStatusApproved, PlanStatusDraft},
+	PlanStatusApproved:  {PlanStatusPublished, PlanStatusDraft},
+	PlanStatusPublished: {PlanStatusArchived},
+	PlanStatusArchived:  {},
+}
+
+// ErrInvalidTransition is wrapped when a plan cannot move to the requested state.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// ErrPlanNotEditable is wrapped when seats of a published or archived plan are changed.
+var ErrPlanNotEditable = errors.New("seating plan can no longer be edited")
+
+// StatusTransition records one lifecycle step of a plan.
+type StatusTransition struct {
+	From      string    `bson:"from" json:"from"`
+	To        string    `bson:"to" json:"to"`
+	Actor     string    `bson:"actor" json:"actor"`           // Email of the user who made the change
+	ActorRole string    `bson:"actor_role" json:"actor_role"` // Role of that user at the time
+	Note      string    `bson:"note,omitempty" json:"note,omitempty"`
+	At        time.Time `bson:"at" json:"at"`
+}
+
+// IsValidPlanStatus reports whether status is a known lifecycle state.
+func IsValidPlanStatus(status string) bool {
+	_, ok := planTransitions[status]
+	return ok
+}
+
+// canTransition reports whether a plan in state from may move to state to.
+// Plans with an unknown (legacy) status are treated as drafts.
+func canTransition(from, to string) bool {
+	if !IsValidPlanStatus(from) {
+		from = PlanStatusDraft
+	}
+	for _, next := range planTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPlanEditable rejects seat changes on plans that students may already have seen.
+func checkPlanEditable(plan *SeatingPlan) error {
+	if plan.Status == PlanStatusPublished || plan.Status == PlanStatusArchived {
+		return fmt.Errorf("%w: plan is %s", ErrPlanNotEditable, plan.Status)
+	}
+	return nil
+}
+
+// archivePublishedVersions archives the other published plans of the exams a newly
+// published plan covers, so students never see two seat assignments for one exam.
+func (s *SeatingService) archivePublishedVersions(ctx context.Context, plan *SeatingPlan, actor, actorRole string, at time.Time) error {
+	for _, examID := range planExamIDs(plan) {
+		plans, err := s.repo.FindSeatingPlansByExam(ctx, examID)
+		if err != nil {
+			return err
+		}
+		for _, other := range plans {
+			if other.ID == plan.ID || other.Status != PlanStatusPublished {
+				continue
+			}
+			other.StatusHistory = append(other.StatusHistory, StatusTransition{
+				From:      other.Status,
+				To:        PlanStatusArchived,
+				Actor:     actor,
+				ActorRole: actorRole,
+				Note:      fmt.Sprintf("replaced by published version %d", plan.Version),
+				At:        at,
+			})
+			other.Status = PlanStatusArchived
+			other.UpdatedAt = at
+			if err := s.repo.UpdateSeatingPlan(ctx, other); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateSeatingPlanStatus moves a plan to a new lifecycle state and records who did it.
+// Publishing a plan archives any earlier published plan of the same exam.
+func (s *SeatingService) UpdateSeatingPlanStatus(ctx context.Context, planID primitive.ObjectID, status, actor, actorRole, note string) (*SeatingPlan, error) {
+	if !IsValidPlanStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, status)
+	}
+	plan, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, errors.New("seating plan not found")
+	}
+	// Superseded plans are frozen, apart from being archived
+	if status != PlanStatusArchived {
+		if err := checkNotSuperseded(plan); err != nil {
+			return nil, err
+		}
+	}
+	if !canTransition(plan.Status, status) {
+		return nil, fmt.Errorf("%w: cannot move a %s plan to %s", ErrInvalidTransition, plan.Status, status)
+	}
+	if status == PlanStatusPublished {
+		if err := s.checkPlanStaffed(ctx, plan); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	plan.StatusHistory = append(plan.StatusHistory, StatusTransition{
+		From:      plan.Status,
+		To:        status,
+		Actor:     actor,
+		ActorRole: actorRole,
+		Note:      note,
+		At:        now,
+	})
+	plan.Status = status
+	plan.UpdatedAt = now
+	if err := s.repo.UpdateSeatingPlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	if status == PlanStatusPublished {
+		if err := s.archivePublishedVersions(ctx, plan, actor, actorRole, now); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// Why: Students must only ever see a plan that someone signed off, and staff need to know who moved it along and when.
@@ -8,8 +8,9 @@ import (
 
 // Student represents a student in the seating system.
 type Student struct {
-	StudentID string `bson:"student_id" json:"student_id"`
-	Name      string `bson:"name" json:"name"`
+	StudentID      string   `bson:"student_id" json:"student_id"`
+	Name           string   `bson:"name" json:"name"`
+	Accommodations []string `bson:"accommodations,omitempty" json:"accommodations,omitempty"` // See Accommodation* constants
 }
 
 // StudentList represents a batch of students uploaded together
@@ -17,6 +18,7 @@ type StudentList struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
 	Department string             `bson:"department" json:"department"`
 	Batch      string             `bson:"batch" json:"batch"`
+	Course     string             `bson:"course,omitempty" json:"course,omitempty"` // Course or paper the list sits, for course grouping
 	Faculty    string             `bson:"faculty" json:"faculty"`
 	Name       string             `bson:"name" json:"name"`
 	Students   []Student          `bson:"students" json:"students"`
@@ -25,12 +27,15 @@ type StudentList struct {
 
 // Room represents an examination room.
 type Room struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty"` // Unique identifier for the room
-	Name     string             `bson:"name"`          // Room name/number
-	Capacity int                `bson:"capacity"`      // Total number of seats (rows * columns)
-	Rows     int                `bson:"rows"`          // Number of rows in the room
-	Columns  int                `bson:"columns"`       // Number of columns in the room
-	Building string             `bson:"building"`      // Building where room is located
+	ID           primitive.ObjectID `bson:"_id,omitempty"` // Unique identifier for the room
+	Name         string             `bson:"name"`          // Room name/number
+	Capacity     int                `bson:"capacity"`      // Number of seatable cells, derived from the layout
+	Rows         int                `bson:"rows"`          // Number of rows in the room
+	Columns      int                `bson:"columns"`       // Number of columns in the room
+	Building     string             `bson:"building"`      // Building where room is located
+	Layout       []LayoutCell       `bson:"layout"`        // Cells that are not plain usable seats (blocked, aisle, accessible, ...)
+	GroundFloor  bool               `bson:"ground_floor"`  // Reachable without stairs
+	SeparateRoom bool               `bson:"separate_room"` // Reserved for students who sit apart, e.g. with extra time
 }
 
 // Invigilator represents an exam invigilator.
@@ -49,6 +54,7 @@ type Exam struct {
 	Duration  int                `bson:"duration"`      // Exam duration in minutes
 	Faculty   string             `bson:"faculty"`       // Faculty conducting the exam
 	Algorithm string             `bson:"algorithm"`     // Preferred seating algorithm (matrix, parallel, random)
+	PaperSets int                `bson:"paper_sets"`    // Number of question-paper variants (A, B, C, ...); 0 or 1 means a single paper
 	CreatedAt time.Time          `bson:"created_at"`    // When the exam was created
 	UpdatedAt time.Time          `bson:"updated_at"`    // When the exam was last updated
 }
@@ -60,15 +66,64 @@ type ExamRoom struct {
 	RoomID         primitive.ObjectID   `bson:"room_id"`          // Reference to the room
 	StudentListIDs []primitive.ObjectID `bson:"student_list_ids"` // References to the student lists assigned to this room
 	Invigilators   []primitive.ObjectID `bson:"invigilators"`     // List of invigilator IDs assigned to this room
+	Roles          []RoomRole           `bson:"roles,omitempty"`  // Role of each invigilator; invigilators missing here are assistants
+	Shared         bool                 `bson:"shared"`           // Room may also host concurrent exams booked as shared, seated in one session plan
+	Draft          bool                 `bson:"draft"`            // Booked by the timetable generator and not yet confirmed
 	CreatedAt      time.Time            `bson:"created_at"`       // When the room was assigned
 	UpdatedAt      time.Time            `bson:"updated_at"`       // When the room was last updated
 }
 
+// RoomRole tags an invigilator of an exam room with their role there.
+type RoomRole struct {
+	InvigilatorID primitive.ObjectID `bson:"invigilator_id" json:"invigilator_id"`
+	Role          string             `bson:"role" json:"role"` // See InvigilatorRole* constants
+}
+
+// RoomBlock takes a room out of use for a period, e.g. for maintenance or an event.
+type RoomBlock struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	RoomID    primitive.ObjectID `bson:"room_id" json:"room_id"`
+	Start     time.Time          `bson:"start" json:"start"`
+	End       time.Time          `bson:"end" json:"end"`
+	Reason    string             `bson:"reason" json:"reason"`
+	CreatedBy string             `bson:"created_by" json:"created_by"` // Email of the user who blocked the room
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Unavailability is a period an invigilator declared they cannot invigilate, e.g. leave.
+type Unavailability struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	InvigilatorID primitive.ObjectID `bson:"invigilator_id" json:"invigilator_id"` // User ID of the member of staff
+	Email         string             `bson:"email" json:"email"`
+	Start         time.Time          `bson:"start" json:"start"`
+	End           time.Time          `bson:"end" json:"end"`
+	Reason        string             `bson:"reason" json:"reason"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// DutySwap is a request by one invigilator to trade an exam room duty with another.
+// The counterpart accepts it and an admin approves it before the rooms change.
+type DutySwap struct {
+	ID                    primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	RequesterID           primitive.ObjectID `bson:"requester_id" json:"requester_id"`
+	RequesterEmail        string             `bson:"requester_email" json:"requester_email"`
+	RequesterExamRoomID   primitive.ObjectID `bson:"requester_exam_room_id" json:"requester_exam_room_id"` // Duty the requester gives up
+	CounterpartID         primitive.ObjectID `bson:"counterpart_id" json:"counterpart_id"`
+	CounterpartEmail      string             `bson:"counterpart_email" json:"counterpart_email"`
+	CounterpartExamRoomID primitive.ObjectID `bson:"counterpart_exam_room_id" json:"counterpart_exam_room_id"` // Duty the requester takes over
+	Reason                string             `bson:"reason" json:"reason"`
+	Status                string             `bson:"status" json:"status"`                             // See SwapStatus* constants
+	DecidedBy             string             `bson:"decided_by,omitempty" json:"decided_by,omitempty"` // Email of the admin who approved or rejected
+	CreatedAt             time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt             time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
 // UserBasicInfo is a minimal user struct for embedding in plans
 // (new struct)
 type UserBasicInfo struct {
 	ID   primitive.ObjectID `bson:"_id" json:"_id"`
 	Name string             `bson:"name" json:"name"`
+	Role string             `bson:"role,omitempty" json:"role,omitempty"` // Invigilator role in the room, see InvigilatorRole* constants
 }
 
 // SeatingPlanRoom represents a room's seating and invigilator assignments within a plan
@@ -83,26 +138,66 @@ type SeatingPlanRoom struct {
 	Invigilators       []primitive.ObjectID `bson:"invigilators" json:"invigilators"`
 	InvigilatorDetails []UserBasicInfo      `bson:"invigilator_details" json:"invigilatorDetails"`
 	Seats              []Seat               `bson:"seats" json:"seats"`
+	Violations         int                  `bson:"violations" json:"violations"`                             // Neighbouring pairs in the same group under the plan's grouping
+	Score              ScoreMetrics         `bson:"score" json:"score"`                                       // Arrangement quality metrics for this room
+	VariantCounts      map[string]int       `bson:"variant_counts,omitempty" json:"variant_counts,omitempty"` // Occupied seats per paper variant, for the exam cell
 	StudentLists       []StudentList        `bson:"student_lists,omitempty" json:"student_lists,omitempty"`
 }
 
 // SeatingPlan represents a seating arrangement for an exam (now includes all rooms)
 type SeatingPlan struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
-	ExamID    primitive.ObjectID `bson:"exam_id" json:"exam_id"`
-	Algorithm string             `bson:"algorithm" json:"algorithm"`
-	Status    string             `bson:"status" json:"status"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
-	Rooms     []SeatingPlanRoom  `bson:"rooms" json:"rooms"`
+	ID               primitive.ObjectID   `bson:"_id,omitempty" json:"_id"`
+	ExamID           primitive.ObjectID   `bson:"exam_id" json:"exam_id"`
+	Algorithm        string               `bson:"algorithm" json:"algorithm"`
+	Mode             string               `bson:"mode,omitempty" json:"mode,omitempty"`                         // Generation mode (per_room or pooled)
+	Distribution     string               `bson:"distribution,omitempty" json:"distribution,omitempty"`         // Cross-room strategy used in pooled mode
+	Spacing          string               `bson:"spacing,omitempty" json:"spacing,omitempty"`                   // Mandatory spacing rule applied to every room
+	Grouping         string               `bson:"grouping,omitempty" json:"grouping,omitempty"`                 // Key students were separated by, see GroupBy* constants; empty means department or batch
+	PaperSets        int                  `bson:"paper_sets,omitempty" json:"paper_sets,omitempty"`             // Number of paper variants handed out, taken from the exam at generation
+	SessionExamIDs   []primitive.ObjectID `bson:"session_exam_ids,omitempty" json:"session_exam_ids,omitempty"` // All exams seated together in this session plan, ExamID first; empty for single-exam plans
+	Seed             int64                `bson:"seed" json:"seed"`                                             // Random seed used for generation, for reproducible audits
+	Parameters       AlgorithmParams      `bson:"parameters,omitempty" json:"parameters,omitempty"`             // Algorithm parameters used for generation
+	Status           string               `bson:"status" json:"status"`                                         // Lifecycle state, see PlanStatus* constants
+	StatusHistory    []StatusTransition   `bson:"status_history,omitempty" json:"status_history,omitempty"`     // Every lifecycle transition with actor and time
+	Version          int                  `bson:"version" json:"version"`                                       // 1-based version number within the exam; 0 for undecided candidates
+	PreviousPlanID   *primitive.ObjectID  `bson:"previous_plan_id,omitempty" json:"previous_plan_id,omitempty"` // Version this plan was regenerated from
+	SupersededBy     *primitive.ObjectID  `bson:"superseded_by,omitempty" json:"superseded_by,omitempty"`       // Newer version that replaced this plan; superseded plans are read-only
+	SupersededAt     *time.Time           `bson:"superseded_at,omitempty" json:"superseded_at,omitempty"`
+	CandidateGroup   *primitive.ObjectID  `bson:"candidate_group,omitempty" json:"candidate_group,omitempty"` // Batch of competing drafts this plan belongs to, until one is promoted
+	CandidateRank    int                  `bson:"candidate_rank,omitempty" json:"candidate_rank,omitempty"`   // 1-based position within its candidate group
+	CreatedAt        time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time            `bson:"updated_at" json:"updated_at"`
+	Rooms            []SeatingPlanRoom    `bson:"rooms" json:"rooms"`
+	Violations       int                  `bson:"violations" json:"violations"`               // Sum of room violations
+	Score            ScoreMetrics         `bson:"score" json:"score"`                         // Arrangement quality metrics across all rooms
+	UnplacedStudents []UnplacedStudent    `bson:"unplaced_students" json:"unplaced_students"` // Students that could not be given a seat
+	Drift            *PlanDrift           `bson:"-" json:"drift,omitempty"`                   // Differences from the current student lists, computed on read
+}
+
+// UnplacedStudent records a student that plan generation could not seat, and why.
+type UnplacedStudent struct {
+	StudentID  string              `bson:"student_id" json:"student_id"`
+	Name       string              `bson:"name" json:"name"`
+	Department string              `bson:"department" json:"department"`
+	Batch      string              `bson:"batch" json:"batch"`
+	Course     string              `bson:"course,omitempty" json:"course,omitempty"`
+	ExamID     *primitive.ObjectID `bson:"exam_id,omitempty" json:"exam_id,omitempty"` // Exam the student sits, in session plans
+	Reason     string              `bson:"reason" json:"reason"`
 }
 
 // Seat represents a single seat assignment in a seating plan.
 type Seat struct {
-	Row       int    `bson:"row"`        // Row number (1-based)
-	Column    int    `bson:"column"`     // Column number (1-based)
-	StudentID string `bson:"student_id"` // Student ID (string)
-	IsEmpty   bool   `bson:"is_empty"`   // Whether the seat is empty
+	Row        int                 `bson:"row"`                  // Row number (1-based)
+	Column     int                 `bson:"column"`               // Column number (1-based)
+	StudentID  string              `bson:"student_id"`           // Student ID (string)
+	IsEmpty    bool                `bson:"is_empty"`             // Whether the seat is empty
+	CellType   string              `bson:"cell_type,omitempty"`  // Layout type for non-usable or accessible cells
+	Department string              `bson:"department,omitempty"` // Department of the seated student, for scoring and reports
+	Batch      string              `bson:"batch,omitempty"`      // Batch of the seated student, for scoring and reports
+	Course     string              `bson:"course,omitempty"`     // Course of the seated student, for course grouping
+	Locked     bool                `bson:"locked,omitempty"`     // Manually fixed seat that regeneration keeps in place
+	Variant    string              `bson:"variant,omitempty"`    // Question-paper variant (A, B, C, ...) handed out at this seat
+	ExamID     *primitive.ObjectID `bson:"exam_id,omitempty"`    // Exam the seated student sits, in session plans
 }
 
 // Why: These models provide the complete data structure for managing exams, rooms, students, invigilators, and seating arrangements with proper relationships and metadata.
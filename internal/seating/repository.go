@@ -3,10 +3,13 @@ package seating
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // User struct for invigilator queries (copied from internal/auth/models.go)
@@ -31,6 +34,9 @@ type SeatingRepository struct {
 	studentListsCollection *mongo.Collection
 	examRoomsCollection    *mongo.Collection
 	usersCollection        *mongo.Collection
+	roomBlocksCollection   *mongo.Collection
+	availabilityCollection *mongo.Collection
+	dutySwapsCollection    *mongo.Collection
 }
 
 // NewSeatingRepository creates a new repository for seating operations.
@@ -44,6 +50,9 @@ func NewSeatingRepository(db *mongo.Database) *SeatingRepository {
 		studentListsCollection: db.Collection("student_lists"),
 		examRoomsCollection:    db.Collection("exam_rooms"),
 		usersCollection:        db.Collection("users"),
+		roomBlocksCollection:   db.Collection("room_blocks"),
+		availabilityCollection: db.Collection("invigilator_unavailability"),
+		dutySwapsCollection:    db.Collection("duty_swaps"),
 	}
 }
 
@@ -116,6 +125,39 @@ func (r *SeatingRepository) DeleteRoom(ctx context.Context, id primitive.ObjectI
 	if res.DeletedCount == 0 {
 		return errors.New("room not found")
 	}
+	// Cascade delete: blocks only make sense for an existing room
+	_, err = r.roomBlocksCollection.DeleteMany(ctx, bson.M{"room_id": id})
+	return err
+}
+
+// RoomBlock operations
+func (r *SeatingRepository) CreateRoomBlock(ctx context.Context, block *RoomBlock) error {
+	_, err := r.roomBlocksCollection.InsertOne(ctx, block)
+	return err
+}
+
+// FindRoomBlocksByRoomIDs returns the manual blocks of the given rooms.
+func (r *SeatingRepository) FindRoomBlocksByRoomIDs(ctx context.Context, roomIDs []primitive.ObjectID) ([]*RoomBlock, error) {
+	filter := bson.M{"room_id": bson.M{"$in": roomIDs}}
+	cursor, err := r.roomBlocksCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var blocks []*RoomBlock
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func (r *SeatingRepository) DeleteRoomBlock(ctx context.Context, roomID, blockID primitive.ObjectID) error {
+	res, err := r.roomBlocksCollection.DeleteOne(ctx, bson.M{"_id": blockID, "room_id": roomID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("room block not found")
+	}
 	return nil
 }
 
@@ -123,11 +165,14 @@ func (r *SeatingRepository) UpdateRoom(ctx context.Context, id primitive.ObjectI
 	filter := bson.M{"_id": id}
 	update := bson.M{
 		"$set": bson.M{
-			"name":     room.Name,
-			"rows":     room.Rows,
-			"columns":  room.Columns,
-			"building": room.Building,
-			"capacity": room.Capacity,
+			"name":          room.Name,
+			"rows":          room.Rows,
+			"columns":       room.Columns,
+			"building":      room.Building,
+			"capacity":      room.Capacity,
+			"layout":        room.Layout,
+			"ground_floor":  room.GroundFloor,
+			"separate_room": room.SeparateRoom,
 		},
 	}
 	res, err := r.roomsCollection.UpdateOne(ctx, filter, update)
@@ -140,6 +185,26 @@ func (r *SeatingRepository) UpdateRoom(ctx context.Context, id primitive.ObjectI
 	return nil
 }
 
+// UpdateRoomLayout replaces a room's grid dimensions, layout cells and derived capacity.
+func (r *SeatingRepository) UpdateRoomLayout(ctx context.Context, id primitive.ObjectID, rows, columns int, layout []LayoutCell, capacity int) error {
+	update := bson.M{
+		"$set": bson.M{
+			"rows":     rows,
+			"columns":  columns,
+			"layout":   layout,
+			"capacity": capacity,
+		},
+	}
+	res, err := r.roomsCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("room not found")
+	}
+	return nil
+}
+
 // Exam operations
 func (r *SeatingRepository) CreateExam(ctx context.Context, exam *Exam) error {
 	_, err := r.examsCollection.InsertOne(ctx, exam)
@@ -268,6 +333,71 @@ func (r *SeatingRepository) UpdateSeatingPlan(ctx context.Context, plan *Seating
 	return nil
 }
 
+// NextSeatingPlanVersion returns one more than the highest plan version of an exam.
+func (r *SeatingRepository) NextSeatingPlanVersion(ctx context.Context, examID primitive.ObjectID) (int, error) {
+	var latest struct {
+		Version int `bson:"version"`
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1})
+	err := r.seatingPlansCollection.FindOne(ctx, bson.M{"exam_id": examID}, opts).Decode(&latest)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 1, nil
+		}
+		return 0, err
+	}
+	return latest.Version + 1, nil
+}
+
+// MarkSeatingPlanSuperseded records that a plan was replaced by a newer version.
+// A plan that is already superseded keeps its original successor.
+func (r *SeatingRepository) MarkSeatingPlanSuperseded(ctx context.Context, id, by primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "superseded_by": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"superseded_by": by, "superseded_at": at}}
+	_, err := r.seatingPlansCollection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// FindSeatingPlansByCandidateGroup returns the plans of one candidate group, best ranked first.
+func (r *SeatingRepository) FindSeatingPlansByCandidateGroup(ctx context.Context, group primitive.ObjectID) ([]*SeatingPlan, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "candidate_rank", Value: 1}})
+	cursor, err := r.seatingPlansCollection.Find(ctx, bson.M{"candidate_group": group}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var plans []*SeatingPlan
+	if err := cursor.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// PromoteCandidatePlan keeps one plan of a candidate group, deleting the others and
+// detaching the kept plan from the group under the given version number, linked to the
+// previous version when there is one. It returns how many candidates were discarded.
+func (r *SeatingRepository) PromoteCandidatePlan(ctx context.Context, planID, group primitive.ObjectID, version int, previous *primitive.ObjectID) (int64, error) {
+	res, err := r.seatingPlansCollection.DeleteMany(ctx, bson.M{"candidate_group": group, "_id": bson.M{"$ne": planID}})
+	if err != nil {
+		return 0, err
+	}
+	set := bson.M{"updated_at": time.Now(), "version": version}
+	if previous != nil {
+		set["previous_plan_id"] = *previous
+	}
+	update := bson.M{
+		"$unset": bson.M{"candidate_group": "", "candidate_rank": ""},
+		"$set":   set,
+	}
+	upd, err := r.seatingPlansCollection.UpdateOne(ctx, bson.M{"_id": planID}, update)
+	if err != nil {
+		return res.DeletedCount, err
+	}
+	if upd.MatchedCount == 0 {
+		return res.DeletedCount, errors.New("seating plan not found")
+	}
+	return res.DeletedCount, nil
+}
+
 // FindSeatingPlansByStudentID returns seating plans where any seat.student_id matches the given StudentID
 func (r *SeatingRepository) FindSeatingPlansByStudentID(ctx context.Context, studentID string) ([]*SeatingPlan, error) {
 	// Try direct query first
@@ -441,6 +571,167 @@ func (r *SeatingRepository) RemoveStudentFromList(ctx context.Context, listID pr
 	return nil
 }
 
+// Unavailability operations
+func (r *SeatingRepository) CreateUnavailability(ctx context.Context, entry *Unavailability) error {
+	_, err := r.availabilityCollection.InsertOne(ctx, entry)
+	return err
+}
+
+// FindUnavailability returns declared unavailability overlapping the window, for one
+// invigilator or, with a nil ID, for everyone. Nil bounds leave the window open.
+func (r *SeatingRepository) FindUnavailability(ctx context.Context, invigilatorID *primitive.ObjectID, from, to *time.Time) ([]*Unavailability, error) {
+	filter := bson.M{}
+	if invigilatorID != nil {
+		filter["invigilator_id"] = *invigilatorID
+	}
+	if from != nil {
+		filter["end"] = bson.M{"$gt": *from}
+	}
+	if to != nil {
+		filter["start"] = bson.M{"$lt": *to}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "start", Value: 1}})
+	cursor, err := r.availabilityCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Unavailability
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *SeatingRepository) DeleteUnavailability(ctx context.Context, id, invigilatorID primitive.ObjectID) error {
+	res, err := r.availabilityCollection.DeleteOne(ctx, bson.M{"_id": id, "invigilator_id": invigilatorID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("unavailability not found")
+	}
+	return nil
+}
+
+// Duty swap operations
+func (r *SeatingRepository) CreateDutySwap(ctx context.Context, swap *DutySwap) error {
+	_, err := r.dutySwapsCollection.InsertOne(ctx, swap)
+	return err
+}
+
+func (r *SeatingRepository) FindDutySwapByID(ctx context.Context, id primitive.ObjectID) (*DutySwap, error) {
+	var swap DutySwap
+	err := r.dutySwapsCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&swap)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &swap, nil
+}
+
+// FindDutySwaps returns swap requests, newest first, involving the invigilator on
+// either side (nil for everyone) and in the given status (empty for any).
+func (r *SeatingRepository) FindDutySwaps(ctx context.Context, invigilatorID *primitive.ObjectID, status string) ([]*DutySwap, error) {
+	filter := bson.M{}
+	if invigilatorID != nil {
+		filter["$or"] = bson.A{bson.M{"requester_id": *invigilatorID}, bson.M{"counterpart_id": *invigilatorID}}
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.dutySwapsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var swaps []*DutySwap
+	if err := cursor.All(ctx, &swaps); err != nil {
+		return nil, err
+	}
+	return swaps, nil
+}
+
+// UpdateDutySwapStatus moves a swap from one status to another. It fails with
+// "duty swap not found" when the swap is no longer in the expected status.
+func (r *SeatingRepository) UpdateDutySwapStatus(ctx context.Context, id primitive.ObjectID, from, to, decidedBy string) error {
+	set := bson.M{"status": to, "updated_at": time.Now()}
+	if decidedBy != "" {
+		set["decided_by"] = decidedBy
+	}
+	res, err := r.dutySwapsCollection.UpdateOne(ctx, bson.M{"_id": id, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("duty swap not found")
+	}
+	return nil
+}
+
+// moveInvigilator replaces one invigilator of an exam room with another, who takes over
+// the role held there. It fails with ErrDutyNotAssigned when from no longer covers the room.
+func (r *SeatingRepository) moveInvigilator(ctx context.Context, examRoomID, from, to primitive.ObjectID) error {
+	filter := bson.M{"_id": examRoomID, "invigilators": from}
+	update := bson.M{"$set": bson.M{"invigilators.$": to, "updated_at": time.Now()}}
+	res, err := r.examRoomsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("%w: exam room %s", ErrDutyNotAssigned, examRoomID.Hex())
+	}
+	filter = bson.M{"_id": examRoomID, "roles.invigilator_id": from}
+	update = bson.M{"$set": bson.M{"roles.$.invigilator_id": to}}
+	if _, err := r.examRoomsCollection.UpdateOne(ctx, filter, update); err != nil {
+		// Put the invigilator back so the room is not left half moved
+		filter = bson.M{"_id": examRoomID, "invigilators": to}
+		update = bson.M{"$set": bson.M{"invigilators.$": from}}
+		if _, undoErr := r.examRoomsCollection.UpdateOne(ctx, filter, update); undoErr != nil {
+			return fmt.Errorf("%v; undoing move failed: %v", err, undoErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// ApplyDutySwap exchanges the two invigilators between their exam rooms and marks the
+// swap approved. Each write is a conditional single-document update, so no replica set is
+// needed; when a later write fails, the moves already made are undone.
+func (r *SeatingRepository) ApplyDutySwap(ctx context.Context, swap *DutySwap, approvedBy string) error {
+	moves := []struct{ examRoomID, from, to primitive.ObjectID }{
+		{swap.RequesterExamRoomID, swap.RequesterID, swap.CounterpartID},
+		{swap.CounterpartExamRoomID, swap.CounterpartID, swap.RequesterID},
+	}
+	done := 0
+	rollback := func(err error) error {
+		for i := done - 1; i >= 0; i-- {
+			if undoErr := r.moveInvigilator(ctx, moves[i].examRoomID, moves[i].to, moves[i].from); undoErr != nil {
+				return fmt.Errorf("%v; undoing swap failed: %v", err, undoErr)
+			}
+		}
+		return err
+	}
+	for _, move := range moves {
+		if err := r.moveInvigilator(ctx, move.examRoomID, move.from, move.to); err != nil {
+			return rollback(err)
+		}
+		done++
+	}
+
+	filter := bson.M{"_id": swap.ID, "status": SwapStatusAccepted}
+	update := bson.M{"$set": bson.M{"status": SwapStatusApproved, "decided_by": approvedBy, "updated_at": time.Now()}}
+	res, err := r.dutySwapsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return rollback(err)
+	}
+	if res.MatchedCount == 0 {
+		return rollback(errors.New("duty swap not found"))
+	}
+	return nil
+}
+
 // ExamRoom operations
 func (r *SeatingRepository) CreateExamRoom(ctx context.Context, examRoom *ExamRoom) error {
 	_, err := r.examRoomsCollection.InsertOne(ctx, examRoom)
@@ -472,19 +763,98 @@ func (r *SeatingRepository) GetExamRooms(ctx context.Context, examID primitive.O
 	return examRooms, nil
 }
 
-func (r *SeatingRepository) AddInvigilatorToRoom(ctx context.Context, examRoomID, invigilatorID primitive.ObjectID) error {
-	filter := bson.M{"_id": examRoomID}
-	update := bson.M{"$addToSet": bson.M{"invigilators": invigilatorID}}
+// FindExamRoomsByRoomIDs returns the assignments of the given rooms across all exams.
+func (r *SeatingRepository) FindExamRoomsByRoomIDs(ctx context.Context, roomIDs []primitive.ObjectID) ([]*ExamRoom, error) {
+	filter := bson.M{"room_id": bson.M{"$in": roomIDs}}
+	cursor, err := r.examRoomsCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var examRooms []*ExamRoom
+	if err := cursor.All(ctx, &examRooms); err != nil {
+		return nil, err
+	}
+	return examRooms, nil
+}
+
+// AddInvigilatorToRoom adds an invigilator with the given role to an exam room. It
+// fails with "invigilator already assigned to this room" instead of adding them twice.
+func (r *SeatingRepository) AddInvigilatorToRoom(ctx context.Context, examRoomID, invigilatorID primitive.ObjectID, role string) error {
+	filter := bson.M{"_id": examRoomID, "invigilators": bson.M{"$ne": invigilatorID}}
+	update := bson.M{"$push": bson.M{
+		"invigilators": invigilatorID,
+		"roles":        RoomRole{InvigilatorID: invigilatorID, Role: role},
+	}}
+	res, err := r.examRoomsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		count, err := r.examRoomsCollection.CountDocuments(ctx, bson.M{"_id": examRoomID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("exam room not found")
+		}
+		return errors.New("invigilator already assigned to this room")
+	}
+	return nil
+}
+
+// SetInvigilatorRole changes the role of an invigilator already assigned to an exam room.
+func (r *SeatingRepository) SetInvigilatorRole(ctx context.Context, examRoomID, invigilatorID primitive.ObjectID, role string) error {
+	now := time.Now()
+	filter := bson.M{"_id": examRoomID, "invigilators": invigilatorID, "roles.invigilator_id": invigilatorID}
+	update := bson.M{"$set": bson.M{"roles.$.role": role, "updated_at": now}}
 	res, err := r.examRoomsCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+	// Assigned before roles existed, so there is no entry to update yet
+	filter = bson.M{"_id": examRoomID, "invigilators": invigilatorID, "roles.invigilator_id": bson.M{"$ne": invigilatorID}}
+	update = bson.M{
+		"$push": bson.M{"roles": RoomRole{InvigilatorID: invigilatorID, Role: role}},
+		"$set":  bson.M{"updated_at": now},
+	}
+	res, err = r.examRoomsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
 	if res.MatchedCount == 0 {
-		return errors.New("exam room not found")
+		return errors.New("invigilator not assigned to this room")
 	}
 	return nil
 }
 
+// FindExamRoomsByInvigilator returns every exam room the invigilator is assigned to.
+func (r *SeatingRepository) FindExamRoomsByInvigilator(ctx context.Context, invigilatorID primitive.ObjectID) ([]*ExamRoom, error) {
+	cursor, err := r.examRoomsCollection.Find(ctx, bson.M{"invigilators": invigilatorID})
+	if err != nil {
+		return nil, err
+	}
+	var examRooms []*ExamRoom
+	if err := cursor.All(ctx, &examRooms); err != nil {
+		return nil, err
+	}
+	return examRooms, nil
+}
+
+// ConfirmExamRooms clears the draft flag on an exam's room assignments and returns how
+// many were confirmed.
+func (r *SeatingRepository) ConfirmExamRooms(ctx context.Context, examID primitive.ObjectID) (int64, error) {
+	filter := bson.M{"exam_id": examID, "draft": true}
+	update := bson.M{"$set": bson.M{"draft": false, "updated_at": time.Now()}}
+	res, err := r.examRoomsCollection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
 // ClearRoomAssignments removes all room assignments for a specific exam.
 func (r *SeatingRepository) ClearRoomAssignments(ctx context.Context, examID primitive.ObjectID) error {
 	collection := r.examRoomsCollection
@@ -511,6 +881,18 @@ func (r *SeatingRepository) GetAllExams(ctx context.Context) ([]*Exam, error) {
 	return exams, nil
 }
 
+func (r *SeatingRepository) GetAllExamRooms(ctx context.Context) ([]*ExamRoom, error) {
+	cursor, err := r.examRoomsCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var examRooms []*ExamRoom
+	if err := cursor.All(ctx, &examRooms); err != nil {
+		return nil, err
+	}
+	return examRooms, nil
+}
+
 func (r *SeatingRepository) GetAllStudents(ctx context.Context) ([]*Student, error) {
 	cursor, err := r.studentsCollection.Find(ctx, bson.M{})
 	if err != nil {
@@ -0,0 +1,186 @@
+package seating
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Penalty weights used to turn adjacency counts into a 0-100 quality score.
+// Orthogonal neighbours can read each other's paper far more easily than diagonal ones,
+// and sharing a department matters more than sharing a batch.
+const (
+	weightDepartmentOrthogonal = 1.0
+	weightDepartmentDiagonal   = 0.5
+	weightBatchOrthogonal      = 0.5
+	weightBatchDiagonal        = 0.25
+)
+
+// ScoreMetrics summarizes the quality of an arrangement for a room or a whole plan.
+type ScoreMetrics struct {
+	SameDepartmentOrthogonal int     `bson:"same_department_orthogonal" json:"same_department_orthogonal"` // Side-by-side or front/back pairs from one department
+	SameDepartmentDiagonal   int     `bson:"same_department_diagonal" json:"same_department_diagonal"`     // Diagonal pairs from one department
+	SameBatchOrthogonal      int     `bson:"same_batch_orthogonal" json:"same_batch_orthogonal"`           // Side-by-side or front/back pairs from one batch
+	SameBatchDiagonal        int     `bson:"same_batch_diagonal" json:"same_batch_diagonal"`               // Diagonal pairs from one batch
+	NeighbourPairs           int     `bson:"neighbour_pairs" json:"neighbour_pairs"`                       // Occupied neighbouring pairs (8-neighbourhood)
+	OccupiedSeats            int     `bson:"occupied_seats" json:"occupied_seats"`
+	SeatableSeats            int     `bson:"seatable_seats" json:"seatable_seats"`
+	EmptySeatRatio           float64 `bson:"empty_seat_ratio" json:"empty_seat_ratio"`           // Share of seatable cells left empty
+	DepartmentClustering     float64 `bson:"department_clustering" json:"department_clustering"` // Share of neighbouring pairs from one department (0 = fully mixed)
+	Score                    float64 `bson:"score" json:"score"`                                 // 0-100, higher is better
+}
+
+// add accumulates raw counts from another set of metrics.
+func (m *ScoreMetrics) add(other ScoreMetrics) {
+	m.SameDepartmentOrthogonal += other.SameDepartmentOrthogonal
+	m.SameDepartmentDiagonal += other.SameDepartmentDiagonal
+	m.SameBatchOrthogonal += other.SameBatchOrthogonal
+	m.SameBatchDiagonal += other.SameBatchDiagonal
+	m.NeighbourPairs += other.NeighbourPairs
+	m.OccupiedSeats += other.OccupiedSeats
+	m.SeatableSeats += other.SeatableSeats
+}
+
+// finalize derives the ratios and the overall score from the raw counts.
+func (m *ScoreMetrics) finalize() {
+	m.EmptySeatRatio = 0
+	if m.SeatableSeats > 0 {
+		m.EmptySeatRatio = float64(m.SeatableSeats-m.OccupiedSeats) / float64(m.SeatableSeats)
+	}
+	m.DepartmentClustering = 0
+	m.Score = 100
+	if m.NeighbourPairs == 0 {
+		return
+	}
+	m.DepartmentClustering = float64(m.SameDepartmentOrthogonal+m.SameDepartmentDiagonal) / float64(m.NeighbourPairs)
+	penalty := weightDepartmentOrthogonal*float64(m.SameDepartmentOrthogonal) +
+		weightDepartmentDiagonal*float64(m.SameDepartmentDiagonal) +
+		weightBatchOrthogonal*float64(m.SameBatchOrthogonal) +
+		weightBatchDiagonal*float64(m.SameBatchDiagonal)
+	m.Score = 100 * (1 - penalty/float64(m.NeighbourPairs))
+	if m.Score < 0 {
+		m.Score = 0
+	}
+}
+
+// scoreRoom computes metrics for one room from its seats alone.
+// Seats must carry Department and Batch (see annotateSeats).
+func scoreRoom(room *SeatingPlanRoom) ScoreMetrics {
+	var m ScoreMetrics
+	occupied := make(map[[2]int]Seat, len(room.Seats))
+	for _, seat := range room.Seats {
+		if isSeatable(seatTypeOrUsable(seat.CellType)) {
+			m.SeatableSeats++
+		}
+		if !seat.IsEmpty && seat.StudentID != "" {
+			occupied[[2]int{seat.Row, seat.Column}] = seat
+		}
+	}
+	m.OccupiedSeats = len(occupied)
+	// Only look "forward" so that each pair is counted once
+	forward := [4][2]int{{0, 1}, {1, 0}, {1, -1}, {1, 1}}
+	for pos, seat := range occupied {
+		for _, off := range forward {
+			other, ok := occupied[[2]int{pos[0] + off[0], pos[1] + off[1]}]
+			if !ok {
+				continue
+			}
+			m.NeighbourPairs++
+			diagonal := off[0] != 0 && off[1] != 0
+			if seat.Department != "" && seat.Department == other.Department {
+				if diagonal {
+					m.SameDepartmentDiagonal++
+				} else {
+					m.SameDepartmentOrthogonal++
+				}
+			}
+			if seat.Batch != "" && seat.Batch == other.Batch {
+				if diagonal {
+					m.SameBatchDiagonal++
+				} else {
+					m.SameBatchOrthogonal++
+				}
+			}
+		}
+	}
+	m.finalize()
+	return m
+}
+
+// scorePlan stores fresh metrics on every room of the plan and on the plan itself.
+func scorePlan(plan *SeatingPlan) {
+	var total ScoreMetrics
+	for i := range plan.Rooms {
+		plan.Rooms[i].Score = scoreRoom(&plan.Rooms[i])
+		total.add(plan.Rooms[i].Score)
+	}
+	total.finalize()
+	plan.Score = total
+}
+
+// RoomReport is the per-room part of a plan quality report.
+type RoomReport struct {
+	RoomID        primitive.ObjectID `json:"room_id"`
+	Name          string             `json:"name"`
+	Building      string             `json:"building"`
+	Violations    int                `json:"violations"`
+	Score         ScoreMetrics       `json:"score"`
+	VariantCounts map[string]int     `json:"variant_counts,omitempty"` // Occupied seats per paper variant
+}
+
+// PlanReport summarizes the quality of a seating plan for comparison before publishing.
+type PlanReport struct {
+	PlanID        primitive.ObjectID `json:"plan_id"`
+	ExamID        primitive.ObjectID `json:"exam_id"`
+	Algorithm     string             `json:"algorithm"`
+	Seed          int64              `json:"seed"`
+	Violations    int                `json:"violations"`
+	UnplacedCount int                `json:"unplaced_count"`
+	Score         ScoreMetrics       `json:"score"`
+	Rooms         []RoomReport       `json:"rooms"`
+}
+
+// newPlanReport builds a report from a plan whose scores are up to date.
+func newPlanReport(plan *SeatingPlan) *PlanReport {
+	report := &PlanReport{
+		PlanID:        plan.ID,
+		ExamID:        plan.ExamID,
+		Algorithm:     plan.Algorithm,
+		Seed:          plan.Seed,
+		Violations:    plan.Violations,
+		UnplacedCount: len(plan.UnplacedStudents),
+		Score:         plan.Score,
+		Rooms:         make([]RoomReport, 0, len(plan.Rooms)),
+	}
+	for _, room := range plan.Rooms {
+		report.Rooms = append(report.Rooms, RoomReport{
+			RoomID:        room.RoomID,
+			Name:          room.Name,
+			Building:      room.Building,
+			Violations:    room.Violations,
+			Score:         room.Score,
+			VariantCounts: room.VariantCounts,
+		})
+	}
+	return report
+}
+
+// seatTypeOrUsable maps the blank Seat.CellType of ordinary seats back to CellUsable.
+func seatTypeOrUsable(t string) string {
+	if t == "" {
+		return CellUsable
+	}
+	return t
+}
+
+// annotateSeats copies department, batch, course and, in session plans, exam onto
+// occupied seats so plans can be scored and compared without reloading student lists.
+func annotateSeats(seats []Seat, students []StudentWithGroup) {
+	byID := make(map[string]StudentWithGroup, len(students))
+	for _, st := range students {
+		byID[st.StudentID] = st
+	}
+	for i := range seats {
+		if st, ok := byID[seats[i].StudentID]; ok && !seats[i].IsEmpty {
+			seatStudent(&seats[i], st)
+		}
+	}
+}
+
+// Why: Admins need numbers, not eyeballing, to compare algorithms before publishing; scoring from seats alone keeps reports cheap and stable after the student lists change.
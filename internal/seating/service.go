@@ -3,8 +3,10 @@ package seating
 import (
 	"context"
 	"errors"
-	"fmt" // Added for debug printing
+	"fmt"
+	"hash/fnv"
 	"math/rand"
+	"sort"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,32 +14,215 @@ import (
 
 // SeatingService handles business logic for seating arrangements.
 type SeatingService struct {
-	repo *SeatingRepository
+	repo       *SeatingRepository
+	algorithms *AlgorithmRegistry
 }
 
 // NewSeatingService creates a new seating service.
-func NewSeatingService(repo *SeatingRepository) *SeatingService {
-	return &SeatingService{repo: repo}
+func NewSeatingService(repo *SeatingRepository, algorithms *AlgorithmRegistry) *SeatingService {
+	return &SeatingService{repo: repo, algorithms: algorithms}
 }
 
-// GenerateSeatingPlan creates a new seating plan using the specified algorithm.
-func (s *SeatingService) GenerateSeatingPlan(ctx context.Context, examID, _ primitive.ObjectID, invigilatorEmail string, algorithm string, _ []primitive.ObjectID) ([]*SeatingPlan, error) {
+// ListAlgorithms returns the seating algorithms available for plan generation.
+func (s *SeatingService) ListAlgorithms() []AlgorithmInfo {
+	return s.algorithms.List()
+}
+
+// HasAlgorithm reports whether an algorithm with the given name is registered.
+func (s *SeatingService) HasAlgorithm(name string) bool {
+	_, ok := s.algorithms.Get(name)
+	return ok
+}
+
+// Generation modes supported by GenerateSeatingPlan.
+const (
+	// GenerationModePerRoom seats each room with the students from its own StudentListIDs.
+	GenerationModePerRoom = "per_room"
+	// GenerationModePooled pools every student list of the exam and spreads them across all assigned rooms.
+	GenerationModePooled = "pooled"
+)
+
+// DistributionStrategies lists the strategies accepted by distributeStudentsAcrossRooms.
+var DistributionStrategies = []string{"matrix", "random", "parallel"}
+
+// GenerateOptions controls how a seating plan is generated.
+type GenerateOptions struct {
+	Algorithm    string               // Registered seating algorithm used inside each room
+	Parameters   AlgorithmParams      // Tunable options for the algorithm
+	Mode         string               // GenerationModePerRoom (default) or GenerationModePooled
+	Distribution string               // Cross-room distribution strategy, pooled mode only
+	Spacing      string               // Mandatory spacing rule (none, every_other_column, checkerboard)
+	Grouping     string               // Key students are separated by (department_or_batch, department, batch, department_batch, course, exam)
+	SessionExams []primitive.ObjectID // Further concurrent exams seated together with this one in shared rooms
+	Seed         *int64               // Seed for all randomness; a fresh one is chosen and recorded when nil
+	Locked       []LockedSeat         // Seats kept exactly as they are, e.g. from manual edits
+}
+
+// roomRand returns the random source used for one room of a run. It depends only on
+// the plan seed and the room ID, so adding or reordering rooms does not change others.
+func roomRand(seed int64, roomID primitive.ObjectID) *rand.Rand {
+	h := fnv.New64a()
+	h.Write(roomID[:])
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
+
+// orderListsByIDs returns lists in the order their IDs were requested, since the
+// database gives no ordering guarantee for $in queries.
+func orderListsByIDs(lists []*StudentList, ids []primitive.ObjectID) []*StudentList {
+	byID := make(map[primitive.ObjectID]*StudentList, len(lists))
+	for _, list := range lists {
+		byID[list.ID] = list
+	}
+	ordered := make([]*StudentList, 0, len(lists))
+	for _, id := range ids {
+		if list, ok := byID[id]; ok {
+			ordered = append(ordered, list)
+			delete(byID, id)
+		}
+	}
+	return ordered
+}
+
+// IsValidDistribution reports whether name is a known cross-room distribution strategy.
+func IsValidDistribution(name string) bool {
+	for _, d := range DistributionStrategies {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveCapacity returns how many students a room can actually seat, based on its layout.
+func effectiveCapacity(room *Room) int {
+	return room.SeatableCount()
+}
+
+// studentsFromLists flattens student lists into StudentWithGroup entries, skipping blank IDs.
+func studentsFromLists(lists []*StudentList) []StudentWithGroup {
+	var students []StudentWithGroup
+	for _, list := range lists {
+		for _, student := range list.Students {
+			if student.StudentID != "" {
+				students = append(students, StudentWithGroup{
+					StudentID:      student.StudentID,
+					Name:           student.Name,
+					Department:     list.Department,
+					Batch:          list.Batch,
+					Course:         list.Course,
+					Accommodations: student.Accommodations,
+				})
+			}
+		}
+	}
+	return students
+}
+
+// unplacedFrom converts students that could not be seated into their reported form.
+func unplacedFrom(students []StudentWithGroup, reason string) []UnplacedStudent {
+	unplaced := make([]UnplacedStudent, 0, len(students))
+	for _, st := range students {
+		entry := UnplacedStudent{
+			StudentID:  st.StudentID,
+			Name:       st.Name,
+			Department: st.Department,
+			Batch:      st.Batch,
+			Course:     st.Course,
+			Reason:     reason,
+		}
+		if !st.ExamID.IsZero() {
+			examID := st.ExamID
+			entry.ExamID = &examID
+		}
+		unplaced = append(unplaced, entry)
+	}
+	return unplaced
+}
+
+// GenerateSeatingPlan creates a new seating plan using the specified algorithm, saved as
+// the exam's next version and superseding its latest one.
+func (s *SeatingService) GenerateSeatingPlan(ctx context.Context, examID primitive.ObjectID, opts GenerateOptions) ([]*SeatingPlan, error) {
+	plan, err := s.buildSeatingPlan(ctx, examID, opts)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.latestPlanVersion(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveNewVersion(ctx, plan, previous); err != nil {
+		return nil, err
+	}
+	return []*SeatingPlan{plan}, nil
+}
+
+// buildSeatingPlan arranges and scores a plan for an exam without saving it.
+func (s *SeatingService) buildSeatingPlan(ctx context.Context, examID primitive.ObjectID, opts GenerateOptions) (*SeatingPlan, error) {
+	seatingAlgorithm, ok := s.algorithms.Get(opts.Algorithm)
+	if !ok {
+		return nil, fmt.Errorf("invalid algorithm specified: %q is not registered", opts.Algorithm)
+	}
+	if opts.Mode == "" {
+		opts.Mode = GenerationModePerRoom
+	}
+	if opts.Mode != GenerationModePerRoom && opts.Mode != GenerationModePooled {
+		return nil, fmt.Errorf("invalid generation mode %q: must be '%s' or '%s'", opts.Mode, GenerationModePerRoom, GenerationModePooled)
+	}
+	if opts.Mode == GenerationModePooled {
+		if opts.Distribution == "" {
+			opts.Distribution = "matrix"
+		}
+		if !IsValidDistribution(opts.Distribution) {
+			return nil, fmt.Errorf("invalid distribution strategy %q", opts.Distribution)
+		}
+	} else {
+		opts.Distribution = ""
+	}
+	if !IsValidSpacing(opts.Spacing) {
+		return nil, fmt.Errorf("invalid spacing %q", opts.Spacing)
+	}
+	if opts.Spacing == "" {
+		opts.Spacing = SpacingNone
+	}
+	if !IsValidGrouping(opts.Grouping) {
+		return nil, fmt.Errorf("invalid grouping %q", opts.Grouping)
+	}
+	if opts.Grouping == "" {
+		opts.Grouping = GroupByDepartmentOrBatch
+	}
+	if opts.Seed == nil {
+		seed := time.Now().UnixNano()
+		opts.Seed = &seed
+	}
+
 	// 1. Fetch exam
 	exam, err := s.repo.FindExamByID(ctx, examID)
 	if err != nil || exam == nil {
 		return nil, errors.New("exam not found")
 	}
 
+	// Exams sharing the session are seated together, their rooms merged per physical room
+	examIDs, err := s.sessionExamIDs(ctx, exam, opts.SessionExams)
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. Fetch exam rooms for this exam
-	examRooms, err := s.repo.GetExamRooms(ctx, examID)
+	examRooms, listExams, err := s.sessionExamRooms(ctx, examIDs)
 	if err != nil || len(examRooms) == 0 {
 		return nil, errors.New("no rooms assigned to this exam")
 	}
+	// Fixed room order keeps pooled distribution reproducible for a given seed
+	sort.Slice(examRooms, func(i, j int) bool {
+		return examRooms[i].ID.Hex() < examRooms[j].ID.Hex()
+	})
 
 	var allRooms []*Room
 	var roomExamRooms []*ExamRoom
-	var roomStudentsList [][]StudentWithGroup
-	assignedStudentIDs := make(map[string]bool)
+	var unplaced []UnplacedStudent
+	var pooledListIDs []primitive.ObjectID
+	requestedStudents := 0
+	seenListIDs := make(map[primitive.ObjectID]bool)
 
 	for _, examRoom := range examRooms {
 		// Fetch room details
@@ -45,64 +230,141 @@ func (s *SeatingService) GenerateSeatingPlan(ctx context.Context, examID, _ prim
 		if err != nil || room == nil {
 			continue // Skip invalid rooms
 		}
+		// Spacing is applied to a per-run copy, never saved on the room itself
+		room = applySpacing(room, opts.Spacing)
 		allRooms = append(allRooms, room)
 		roomExamRooms = append(roomExamRooms, examRoom)
+	}
+
+	if len(allRooms) == 0 {
+		return nil, errors.New("no valid rooms assigned to this exam")
+	}
+
+	// Locked seats are reserved before anyone else is placed
+	roomLocks, lockedIDs := matchLocks(allRooms, opts.Locked)
+	lockedStudents := make(map[string]StudentWithGroup)
+	withoutLocked := func(students []StudentWithGroup) []StudentWithGroup {
+		kept := students[:0:0]
+		for _, st := range students {
+			if lockedIDs[st.StudentID] {
+				lockedStudents[st.StudentID] = st
+				continue
+			}
+			kept = append(kept, st)
+		}
+		return kept
+	}
+
+	// 3. Gather students, per room from its own lists or once for the whole exam when pooled
+	roomStudentsList := make([][]StudentWithGroup, len(allRooms))
+	var pooled []StudentWithGroup
+	for i, examRoom := range roomExamRooms {
+		if opts.Mode == GenerationModePooled {
+			// Students are gathered once for the whole exam below
+			for _, id := range examRoom.StudentListIDs {
+				if !seenListIDs[id] {
+					seenListIDs[id] = true
+					pooledListIDs = append(pooledListIDs, id)
+				}
+			}
+			continue
+		}
 
 		// Fetch all student lists for this room
 		studentLists, err := s.repo.FindStudentListsByIDs(ctx, examRoom.StudentListIDs)
 		if err != nil || len(studentLists) == 0 {
-			roomStudentsList = append(roomStudentsList, []StudentWithGroup{})
+			roomStudentsList[i] = []StudentWithGroup{}
 			continue
 		}
+		roomStudentsList[i] = withoutLocked(assignGroups(examStudentsFromLists(orderListsByIDs(studentLists, examRoom.StudentListIDs), listExams), opts.Grouping))
+	}
 
-		// Gather unassigned students from all lists for this room
-		var studentsForRoom []StudentWithGroup
-		for _, list := range studentLists {
-			for _, student := range list.Students {
-				if student.StudentID != "" {
-					studentsForRoom = append(studentsForRoom, StudentWithGroup{
-						StudentID:  student.StudentID,
-						Name:       student.Name,
-						Department: list.Department,
-						Batch:      list.Batch,
-					})
-				}
+	if opts.Mode == GenerationModePooled {
+		studentLists, err := s.repo.FindStudentListsByIDs(ctx, pooledListIDs)
+		if err != nil {
+			return nil, err
+		}
+		// A student may appear in more than one list; seat them only once
+		seenStudents := make(map[string]bool)
+		for _, st := range assignGroups(examStudentsFromLists(orderListsByIDs(studentLists, pooledListIDs), listExams), opts.Grouping) {
+			if seenStudents[st.StudentID] {
+				continue
 			}
+			seenStudents[st.StudentID] = true
+			pooled = append(pooled, st)
 		}
-		// Debug log: print all students being assigned to this room
-		var ids []string
-		for _, s := range studentsForRoom {
-			ids = append(ids, s.StudentID)
+		pooled = withoutLocked(pooled)
+	}
+
+	// Students with accommodations come next, on matching seats in matching rooms
+	var accommodated []StudentWithGroup
+	var homeRooms []int
+	for i := range roomStudentsList {
+		roomStudentsList[i] = splitAccommodated(roomStudentsList[i], i, &accommodated, &homeRooms)
+	}
+	pooled = splitAccommodated(pooled, -1, &accommodated, &homeRooms)
+	roomAccommodations, err := placeAccommodations(allRooms, roomLocks, lockedStudents, accommodated, homeRooms)
+	if err != nil {
+		return nil, err
+	}
+	accommodatedStudents := make(map[string]StudentWithGroup, len(accommodated))
+	for _, st := range accommodated {
+		accommodatedStudents[st.StudentID] = st
+	}
+
+	// seatRooms are what the algorithms see: locked and accommodated cells are taken out
+	seatRooms := make([]*Room, len(allRooms))
+	for i, room := range allRooms {
+		seatRooms[i] = reserveLockedCells(room, append(append([]LockedSeat(nil), roomLocks[i]...), roomAccommodations[i]...))
+	}
+
+	if opts.Mode == GenerationModePooled {
+		// Separate rooms only ever hold students who need one
+		var openRooms []*Room
+		var openIdx []int
+		for i, room := range seatRooms {
+			if !room.SeparateRoom {
+				openRooms = append(openRooms, room)
+				openIdx = append(openIdx, i)
+			}
 		}
-		fmt.Printf("[DEBUG] StudentIDs for room %s: %+v\n", room.Name, ids)
-		// Only assign up to room capacity
-		if len(studentsForRoom) > room.Capacity {
-			studentsForRoom = studentsForRoom[:room.Capacity]
+		distributionRand := rand.New(rand.NewSource(*opts.Seed))
+		distributed, overflow := s.distributeStudentsAcrossRooms(pooled, openRooms, opts.Distribution, distributionRand)
+		for k, i := range openIdx {
+			roomStudentsList[i] = distributed[k]
 		}
-		// Mark these students as assigned
-		for _, s := range studentsForRoom {
-			assignedStudentIDs[s.StudentID] = true
+		for i := range roomStudentsList {
+			if roomStudentsList[i] == nil {
+				roomStudentsList[i] = []StudentWithGroup{}
+			}
+		}
+		unplaced = append(unplaced, unplacedFrom(overflow, "not enough seats across assigned rooms")...)
+	} else {
+		for i, room := range seatRooms {
+			studentsForRoom := roomStudentsList[i]
+			requestedStudents += len(studentsForRoom)
+			// Only assign up to room capacity, reporting the rest
+			if capacity := effectiveCapacity(room); len(studentsForRoom) > capacity {
+				unplaced = append(unplaced, unplacedFrom(studentsForRoom[capacity:], "room "+room.Name+" is full")...)
+				roomStudentsList[i] = studentsForRoom[:capacity]
+			}
 		}
-		roomStudentsList = append(roomStudentsList, studentsForRoom)
 	}
 
-	// 4. Calculate total capacity
+	// 4. Calculate total capacity (after layout, spacing, locked and accommodated seats)
 	totalCapacity := 0
-	for _, room := range allRooms {
-		totalCapacity += room.Capacity
+	for _, room := range seatRooms {
+		totalCapacity += effectiveCapacity(room)
 	}
 
-	totalStudents := 0
-	for _, students := range roomStudentsList {
-		totalStudents += len(students)
-	}
-
-	if totalStudents > totalCapacity {
-		return nil, errors.New("total students exceed total room capacity")
+	// Pooled mode reports overflow per student instead of failing outright
+	if opts.Mode == GenerationModePerRoom && requestedStudents > totalCapacity {
+		return nil, fmt.Errorf("total students exceed total room capacity: %d students, %d seats with spacing '%s'", requestedStudents, totalCapacity, opts.Spacing)
 	}
 
 	// 5. Build the plan with all rooms, applying the algorithm per room
 	planRooms := make([]SeatingPlanRoom, 0)
+	totalViolations := 0
 	for i, room := range allRooms {
 		examRoom := roomExamRooms[i]
 
@@ -114,6 +376,7 @@ func (s *SeatingService) GenerateSeatingPlan(ctx context.Context, examID, _ prim
 				invigilatorDetails = append(invigilatorDetails, UserBasicInfo{
 					ID:   user.ID,
 					Name: user.Name,
+					Role: invigilatorRole(examRoom, invID),
 				})
 			}
 		}
@@ -123,45 +386,36 @@ func (s *SeatingService) GenerateSeatingPlan(ctx context.Context, examID, _ prim
 
 		if len(roomStudents) > 0 {
 			// Generate seats for this room using the specified algorithm
-			switch algorithm {
-			case "parallel":
-				seats = s.generateParallelSeating(room, roomStudents)
-			case "simple":
-				seats = s.generateRandomSeating(room, roomStudents)
-			case "separated":
-				var err error
-				seats, err = s.generateSnakeSeating(room, roomStudents)
-				if err != nil {
-					return nil, err
-				}
-			default:
-				return nil, errors.New("invalid algorithm specified: must be 'parallel', 'simple', or 'separated'")
+			seats, err = seatingAlgorithm.Arrange(seatRooms[i], roomStudents, ArrangeOptions{
+				Params: opts.Parameters,
+				Rand:   roomRand(*opts.Seed, room.ID),
+			})
+			if err != nil {
+				return nil, err
 			}
 		} else {
 			// Create empty seats for this room
-			seats = make([]Seat, room.Rows*room.Columns)
-			for i := 0; i < room.Rows*room.Columns; i++ {
-				row := i / room.Columns
-				col := i % room.Columns
-				seats[i] = Seat{
-					Row:     row + 1,
-					Column:  col + 1,
-					IsEmpty: true,
-				}
-			}
+			seats = emptySeats(seatRooms[i])
 		}
+		roomStudents = append(roomStudents, placeReservedSeats(seats, room, roomLocks[i], lockedStudents, true)...)
+		roomStudents = append(roomStudents, placeReservedSeats(seats, room, roomAccommodations[i], accommodatedStudents, false)...)
+		annotateSeats(seats, roomStudents)
+		assignPaperVariants(seats, exam.PaperSets)
 
 		planRoom := SeatingPlanRoom{
 			RoomID:             room.ID,
 			Name:               room.Name,
 			Building:           room.Building,
-			Capacity:           room.Capacity,
+			Capacity:           effectiveCapacity(room),
 			Rows:               room.Rows,
 			Columns:            room.Columns,
 			Invigilators:       examRoom.Invigilators,
 			InvigilatorDetails: invigilatorDetails,
 			Seats:              seats,
+			Violations:         countSeatViolations(seats, roomStudents),
+			VariantCounts:      countPaperVariants(seats),
 		}
+		totalViolations += planRoom.Violations
 		planRooms = append(planRooms, planRoom)
 	}
 
@@ -169,52 +423,71 @@ func (s *SeatingService) GenerateSeatingPlan(ctx context.Context, examID, _ prim
 	if planRooms == nil {
 		planRooms = []SeatingPlanRoom{}
 	}
+	if unplaced == nil {
+		unplaced = []UnplacedStudent{}
+	}
 
 	plan := &SeatingPlan{
-		ID:        primitive.NewObjectID(),
-		ExamID:    examID,
-		Algorithm: algorithm,
-		Status:    "draft",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Rooms:     planRooms,
-	}
-	err = s.repo.CreateSeatingPlan(ctx, plan)
-	if err != nil {
-		return nil, err
+		ID:               primitive.NewObjectID(),
+		ExamID:           examID,
+		Algorithm:        opts.Algorithm,
+		Mode:             opts.Mode,
+		Distribution:     opts.Distribution,
+		Spacing:          opts.Spacing,
+		Grouping:         opts.Grouping,
+		PaperSets:        exam.PaperSets,
+		SessionExamIDs:   sessionOnly(examIDs),
+		Seed:             *opts.Seed,
+		Parameters:       opts.Parameters,
+		Status:           PlanStatusDraft,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+		Rooms:            planRooms,
+		Violations:       totalViolations,
+		UnplacedStudents: unplaced,
 	}
-
-	return []*SeatingPlan{plan}, nil
+	scorePlan(plan)
+	return plan, nil
 }
 
-// distributeStudentsAcrossRooms distributes students sequentially across rooms, filling each room up to its capacity.
-func (s *SeatingService) distributeStudentsAcrossRooms(allStudents []StudentWithGroup, rooms []*Room, algorithm string) [][]StudentWithGroup {
-	fmt.Printf("[DEBUG] Algorithm: %s\n", algorithm)
-	fmt.Printf("[DEBUG] Total students to distribute: %d\n", len(allStudents))
-	deptCount := map[string]int{}
-	for _, s := range allStudents {
-		deptCount[s.Department]++
-	}
-	fmt.Printf("[DEBUG] Students per department: %v\n", deptCount)
+// distributeStudentsAcrossRooms spreads students over the rooms using the given strategy,
+// filling each room up to its capacity. Students that do not fit anywhere are returned
+// separately as overflow, in their original order.
+func (s *SeatingService) distributeStudentsAcrossRooms(allStudents []StudentWithGroup, rooms []*Room, algorithm string, rng *rand.Rand) ([][]StudentWithGroup, []StudentWithGroup) {
 	result := make([][]StudentWithGroup, len(rooms))
 	for i := range result {
 		result[i] = make([]StudentWithGroup, 0)
 	}
+	capacities := make([]int, len(rooms))
+	for i, room := range rooms {
+		capacities[i] = effectiveCapacity(room)
+	}
+	// nextRoomWithSpace returns the first room at or after idx that still has a free seat, or -1.
+	nextRoomWithSpace := func(idx int) int {
+		for tries := 0; tries < len(rooms); tries++ {
+			i := (idx + tries) % len(rooms)
+			if len(result[i]) < capacities[i] {
+				return i
+			}
+		}
+		return -1
+	}
 
 	switch algorithm {
 	case "matrix":
-		// Group students by department
+		// Group students by their separation group
 		deptMap := map[string][]StudentWithGroup{}
 		var depts []string
 		for _, s := range allStudents {
-			if _, ok := deptMap[s.Department]; !ok {
-				depts = append(depts, s.Department)
+			key := groupBucket(s.Group)
+			if _, ok := deptMap[key]; !ok {
+				depts = append(depts, key)
 			}
-			deptMap[s.Department] = append(deptMap[s.Department], s)
+			deptMap[key] = append(deptMap[key], s)
 		}
 		// For each room, assign as even a split as possible
-		for roomIdx, room := range rooms {
-			cap := room.Capacity
+		for roomIdx := range rooms {
+			cap := capacities[roomIdx]
 			totalLeft := 0
 			for _, d := range depts {
 				totalLeft += len(deptMap[d])
@@ -268,41 +541,43 @@ func (s *SeatingService) distributeStudentsAcrossRooms(allStudents []StudentWith
 		// Shuffle all students
 		students := make([]StudentWithGroup, len(allStudents))
 		copy(students, allStudents)
-		rand.Seed(time.Now().UnixNano())
-		rand.Shuffle(len(students), func(i, j int) { students[i], students[j] = students[j], students[i] })
+		rng.Shuffle(len(students), func(i, j int) { students[i], students[j] = students[j], students[i] })
 		// Assign to rooms in round-robin order
 		roomIdx := 0
 		for _, s := range students {
-			for result[roomIdx] != nil && len(result[roomIdx]) >= rooms[roomIdx].Capacity {
-				roomIdx = (roomIdx + 1) % len(rooms)
+			roomIdx = nextRoomWithSpace(roomIdx)
+			if roomIdx < 0 {
+				break // every room is full
 			}
 			result[roomIdx] = append(result[roomIdx], s)
 			roomIdx = (roomIdx + 1) % len(rooms)
 		}
 	case "parallel":
-		// Fill each room with as much of a department as possible before moving to the next
+		// Fill each room with as much of a group as possible before moving to the next
 		deptMap := map[string][]StudentWithGroup{}
 		var depts []string
 		for _, s := range allStudents {
-			if _, ok := deptMap[s.Department]; !ok {
-				depts = append(depts, s.Department)
+			key := groupBucket(s.Group)
+			if _, ok := deptMap[key]; !ok {
+				depts = append(depts, key)
 			}
-			deptMap[s.Department] = append(deptMap[s.Department], s)
+			deptMap[key] = append(deptMap[key], s)
 		}
 		roomIdx := 0
+	fill:
 		for _, dept := range depts {
 			students := deptMap[dept]
 			idx := 0
 			for idx < len(students) {
-				capLeft := rooms[roomIdx].Capacity - len(result[roomIdx])
+				if roomIdx >= len(rooms) {
+					break fill
+				}
+				capLeft := capacities[roomIdx] - len(result[roomIdx])
 				toAssign := min(capLeft, len(students)-idx)
 				result[roomIdx] = append(result[roomIdx], students[idx:idx+toAssign]...)
 				idx += toAssign
-				if len(result[roomIdx]) >= rooms[roomIdx].Capacity {
+				if len(result[roomIdx]) >= capacities[roomIdx] {
 					roomIdx++
-					if roomIdx >= len(rooms) {
-						break
-					}
 				}
 			}
 		}
@@ -310,23 +585,28 @@ func (s *SeatingService) distributeStudentsAcrossRooms(allStudents []StudentWith
 		// Fallback: sequential fill
 		idx := 0
 		for _, s := range allStudents {
-			for result[idx] != nil && len(result[idx]) >= rooms[idx].Capacity {
-				idx = (idx + 1) % len(rooms)
+			idx = nextRoomWithSpace(idx)
+			if idx < 0 {
+				break // every room is full
 			}
 			result[idx] = append(result[idx], s)
 			idx = (idx + 1) % len(rooms)
 		}
 	}
 
-	fmt.Println("[DEBUG] Department composition per room:")
-	for i, roomStudents := range result {
-		deptCount := map[string]int{}
+	placed := make(map[string]bool)
+	for _, roomStudents := range result {
 		for _, s := range roomStudents {
-			deptCount[s.Department]++
+			placed[s.StudentID] = true
 		}
-		fmt.Printf("Room %d: %v\n", i+1, deptCount)
 	}
-	return result
+	var overflow []StudentWithGroup
+	for _, s := range allStudents {
+		if !placed[s.StudentID] {
+			overflow = append(overflow, s)
+		}
+	}
+	return result, overflow
 }
 
 func min(a, b int) int {
@@ -337,262 +617,140 @@ func min(a, b int) int {
 }
 
 type StudentWithGroup struct {
-	StudentID  string
-	Name       string
-	Department string
-	Batch      string
-}
-
-// generateParallelSeating arranges students by department per column.
-func (s *SeatingService) generateParallelSeating(room *Room, students []StudentWithGroup) []Seat {
-	fmt.Printf("[DEBUG] generateParallelSeating CALLED for room: %s with %d students\n", room.Name, len(students))
-	seats := make([]Seat, room.Rows*room.Columns)
-	// Group students by department
-	deptMap := map[string][]StudentWithGroup{}
-	var depts []string
-	for _, student := range students {
-		if _, ok := deptMap[student.Department]; !ok {
-			depts = append(depts, student.Department)
-		}
-		deptMap[student.Department] = append(deptMap[student.Department], student)
-	}
-	// Assign each department to a column (cycle if more columns than depts)
-	studentIndex := 0
-	colDept := make([]string, room.Columns)
-	for i := 0; i < room.Columns; i++ {
-		colDept[i] = depts[i%len(depts)]
-	}
-	// For each column, fill with students from the assigned department
-	colStudentIdx := make(map[string]int)
-	for j := 0; j < room.Columns; j++ {
-		dept := colDept[j]
-		for i := 0; i < room.Rows; i++ {
-			seatIndex := i*room.Columns + j
-			idx := colStudentIdx[dept]
-			if idx < len(deptMap[dept]) {
-				s := deptMap[dept][idx]
-				seats[seatIndex] = Seat{
-					Row:       i + 1,
-					Column:    j + 1,
-					StudentID: s.StudentID, // Always set StudentID
-					IsEmpty:   false,
-				}
-				colStudentIdx[dept]++
-				studentIndex++
-			} else {
-				seats[seatIndex] = Seat{
-					Row:       i + 1,
-					Column:    j + 1,
-					StudentID: "", // Explicitly set to empty string
-					IsEmpty:   true,
-				}
-			}
-		}
+	StudentID      string
+	Name           string
+	Department     string
+	Batch          string
+	Course         string
+	ExamID         primitive.ObjectID // Exam the student sits; only set in session plans
+	Group          string             // Separation key under the run's grouping, see assignGroups
+	Accommodations []string
+}
+
+// RegenerateSeatingPlan re-runs generation for an existing plan with its recorded
+// algorithm, options and seed, keeping its locked seats in place. The new plan is
+// saved as the next version, superseding the original, and the boolean reports whether
+// every seat matches the original, which holds as long as rooms and lists are unchanged.
+func (s *SeatingService) RegenerateSeatingPlan(ctx context.Context, planID primitive.ObjectID) (*SeatingPlan, bool, error) {
+	original, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil {
+		return nil, false, err
 	}
-	// Debug log
-	fmt.Printf("[DEBUG] generateParallelSeating: first 5 seats: %+v\n", seats[:min(5, len(seats))])
-	var studentIDs []string
-	for i := 0; i < min(5, len(seats)); i++ {
-		studentIDs = append(studentIDs, seats[i].StudentID)
-	}
-	fmt.Printf("[DEBUG] generateParallelSeating: first 5 seat StudentIDs: %+v\n", studentIDs)
-	// Debug log
-	fmt.Printf("[DEBUG] generateParallelSeating: ALL seat StudentIDs for room %s: %+v\n", room.Name, studentIDs)
-	return seats
-}
-
-// generateRandomSeating arranges students in a classic snake/serpentine (row-wise, alternating direction) order, interleaving departments in round-robin order, with no adjacency constraints.
-func (s *SeatingService) generateRandomSeating(room *Room, students []StudentWithGroup) []Seat {
-	fmt.Printf("[DEBUG] generateRandomSeating (classic snake/serpentine, round-robin interleaving) CALLED for room: %s with %d students\n", room.Name, len(students))
-	seats := make([]Seat, room.Rows*room.Columns)
-	// Group students by department
-	deptMap := map[string][]StudentWithGroup{}
-	var depts []string
-	for _, s := range students {
-		if _, ok := deptMap[s.Department]; !ok {
-			depts = append(depts, s.Department)
-		}
-		deptMap[s.Department] = append(deptMap[s.Department], s)
-	}
-	studentCount := len(students)
-	studentIndex := 0
-	deptIdx := 0
-	for i := 0; i < room.Rows; i++ {
-		if i%2 == 0 { // Even row: left-to-right
-			for j := 0; j < room.Columns; j++ {
-				seatIdx := i*room.Columns + j
-				if studentIndex < studentCount {
-					// Find next department with students left
-					tries := 0
-					for tries < len(depts) {
-						dept := depts[deptIdx%len(depts)]
-						if len(deptMap[dept]) > 0 {
-							s := deptMap[dept][0]
-							deptMap[dept] = deptMap[dept][1:]
-							seats[seatIdx] = Seat{
-								Row:       i + 1,
-								Column:    j + 1,
-								StudentID: s.StudentID,
-								IsEmpty:   false,
-							}
-							studentIndex++
-							deptIdx++
-							break
-						} else {
-							deptIdx++
-							tries++
-						}
-					}
-					if tries == len(depts) {
-						// No students left in any department
-						seats[seatIdx] = Seat{
-							Row:     i + 1,
-							Column:  j + 1,
-							IsEmpty: true,
-						}
-					}
-				} else {
-					seats[seatIdx] = Seat{
-						Row:     i + 1,
-						Column:  j + 1,
-						IsEmpty: true,
-					}
-				}
-			}
-		} else { // Odd row: right-to-left
-			for j := room.Columns - 1; j >= 0; j-- {
-				seatIdx := i*room.Columns + j
-				if studentIndex < studentCount {
-					// Find next department with students left
-					tries := 0
-					for tries < len(depts) {
-						dept := depts[deptIdx%len(depts)]
-						if len(deptMap[dept]) > 0 {
-							s := deptMap[dept][0]
-							deptMap[dept] = deptMap[dept][1:]
-							seats[seatIdx] = Seat{
-								Row:       i + 1,
-								Column:    j + 1,
-								StudentID: s.StudentID,
-								IsEmpty:   false,
-							}
-							studentIndex++
-							deptIdx++
-							break
-						} else {
-							deptIdx++
-							tries++
-						}
-					}
-					if tries == len(depts) {
-						// No students left in any department
-						seats[seatIdx] = Seat{
-							Row:     i + 1,
-							Column:  j + 1,
-							IsEmpty: true,
-						}
-					}
-				} else {
-					seats[seatIdx] = Seat{
-						Row:     i + 1,
-						Column:  j + 1,
-						IsEmpty: true,
-					}
-				}
-			}
-		}
+	if original == nil {
+		return nil, false, errors.New("seating plan not found")
 	}
-	return seats
-}
-
-// generateSnakeSeating arranges students to minimize same-department adjacency in both rows and columns.
-func (s *SeatingService) generateSnakeSeating(room *Room, students []StudentWithGroup) ([]Seat, error) {
-	fmt.Printf("[DEBUG] generateSnakeSeating (robust empty seats) CALLED for room: %s with %d students\n", room.Name, len(students))
-	seats := make([]Seat, room.Rows*room.Columns)
-	// Group students by department
-	deptMap := map[string][]StudentWithGroup{}
-	for _, s := range students {
-		deptMap[s.Department] = append(deptMap[s.Department], s)
-	}
-	// Helper: get department of a student by StudentID
-	studentDept := map[string]string{}
-	for _, s := range students {
-		studentDept[s.StudentID] = s.Department
-	}
-	for i := 0; i < room.Rows; i++ {
-		for j := 0; j < room.Columns; j++ {
-			seatIdx := i*room.Columns + j
-			// Check adjacent seats (above and left)
-			adjDepts := map[string]bool{}
-			if i > 0 {
-				above := seats[(i-1)*room.Columns+j]
-				if above.StudentID != "" {
-					if dept, ok := studentDept[above.StudentID]; ok {
-						adjDepts[dept] = true
-					}
-				}
-			}
-			if j > 0 {
-				left := seats[i*room.Columns+(j-1)]
-				if left.StudentID != "" {
-					if dept, ok := studentDept[left.StudentID]; ok {
-						adjDepts[dept] = true
-					}
-				}
-			}
-			// Find all departments with students left that are NOT adjacent
-			candidates := []string{}
-			for dept, group := range deptMap {
-				if len(group) > 0 && !adjDepts[dept] {
-					candidates = append(candidates, dept)
+	seed := original.Seed
+	plan, err := s.buildSeatingPlan(ctx, original.ExamID, GenerateOptions{
+		Algorithm:    original.Algorithm,
+		Parameters:   original.Parameters,
+		Mode:         original.Mode,
+		Distribution: original.Distribution,
+		Spacing:      original.Spacing,
+		Grouping:     original.Grouping,
+		SessionExams: sessionOthers(original),
+		Seed:         &seed,
+		Locked:       lockedSeatsOf(original),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.saveNewVersion(ctx, plan, original); err != nil {
+		return nil, false, err
+	}
+	return plan, sameSeatAssignments(original, plan), nil
+}
+
+// sameSeatAssignments reports whether two plans seat every student identically.
+func sameSeatAssignments(a, b *SeatingPlan) bool {
+	seatKey := func(plan *SeatingPlan) map[string]string {
+		keys := make(map[string]string)
+		for _, room := range plan.Rooms {
+			for _, seat := range room.Seats {
+				if !seat.IsEmpty && seat.StudentID != "" {
+					keys[seat.StudentID] = fmt.Sprintf("%s/%d/%d", room.RoomID.Hex(), seat.Row, seat.Column)
 				}
 			}
-			if len(candidates) == 0 {
-				// No valid department, leave seat empty
-				seats[seatIdx] = Seat{Row: i + 1, Column: j + 1, IsEmpty: true}
-				continue
-			}
-			// Pick the first available department
-			dept := candidates[0]
-			s := deptMap[dept][0]
-			deptMap[dept] = deptMap[dept][1:]
-			seats[seatIdx] = Seat{
-				Row:       i + 1,
-				Column:    j + 1,
-				StudentID: s.StudentID,
-				IsEmpty:   false,
-			}
 		}
+		return keys
 	}
-	// After assignment, check if any students remain unassigned
-	unassigned := 0
-	for _, group := range deptMap {
-		unassigned += len(group)
+	ka, kb := seatKey(a), seatKey(b)
+	if len(ka) != len(kb) {
+		return false
 	}
-	if unassigned > 0 {
-		return nil, fmt.Errorf("Not all students can be accommodated with the current constraints. Unassigned students: %d", unassigned)
+	for id, pos := range ka {
+		if kb[id] != pos {
+			return false
+		}
 	}
-	return seats, nil
+	return true
 }
 
-// GetSeatingPlan retrieves a seating plan by ID.
-func (s *SeatingService) GetSeatingPlan(ctx context.Context, planID primitive.ObjectID) (*SeatingPlan, error) {
-	return s.repo.FindSeatingPlanByID(ctx, planID)
+// GetSeatingPlanReport scores a plan and returns its quality report. Plans created
+// before seats carried department and batch are filled in from the exam's student lists.
+func (s *SeatingService) GetSeatingPlanReport(ctx context.Context, planID primitive.ObjectID) (*PlanReport, error) {
+	plan, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, errors.New("seating plan not found")
+	}
+	if err := s.annotatePlanSeats(ctx, plan); err != nil {
+		return nil, err
+	}
+	scorePlan(plan)
+	return newPlanReport(plan), nil
 }
 
-// UpdateSeatingPlanStatus updates the status of a seating plan.
-func (s *SeatingService) UpdateSeatingPlanStatus(ctx context.Context, planID primitive.ObjectID, status string) error {
-	plan, err := s.repo.FindSeatingPlanByID(ctx, planID)
+// annotatePlanSeats fills in department and batch on occupied seats that lack them.
+func (s *SeatingService) annotatePlanSeats(ctx context.Context, plan *SeatingPlan) error {
+	missing := false
+	for _, room := range plan.Rooms {
+		for _, seat := range room.Seats {
+			if !seat.IsEmpty && seat.StudentID != "" && seat.Department == "" {
+				missing = true
+			}
+		}
+	}
+	if !missing {
+		return nil
+	}
+	examRooms, listExams, err := s.sessionExamRooms(ctx, planExamIDs(plan))
 	if err != nil {
 		return err
 	}
-	if plan == nil {
-		return errors.New("seating plan not found")
+	var listIDs []primitive.ObjectID
+	for _, er := range examRooms {
+		listIDs = append(listIDs, er.StudentListIDs...)
+	}
+	lists, err := s.repo.FindStudentListsByIDs(ctx, listIDs)
+	if err != nil {
+		return err
 	}
+	students := examStudentsFromLists(lists, listExams)
+	for i := range plan.Rooms {
+		annotateSeats(plan.Rooms[i].Seats, students)
+	}
+	return nil
+}
 
-	plan.Status = status
-	plan.UpdatedAt = time.Now()
-	return s.repo.UpdateSeatingPlan(ctx, plan)
+// GetSeatingPlan retrieves a seating plan by ID. Current plans carry their drift from
+// the student lists when they are out of sync.
+func (s *SeatingService) GetSeatingPlan(ctx context.Context, planID primitive.ObjectID) (*SeatingPlan, error) {
+	plan, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil || plan == nil {
+		return plan, err
+	}
+	if plan.SupersededBy == nil && plan.CandidateGroup == nil {
+		roster, err := s.loadExamRoster(ctx, plan)
+		if err != nil {
+			return nil, err
+		}
+		if drift := computeDrift(plan, roster); !drift.InSync {
+			plan.Drift = drift
+		}
+	}
+	return plan, nil
 }
 
 // DeleteSeatingPlan deletes a seating plan by ID.
@@ -635,15 +793,126 @@ func (s *SeatingService) GetExamRooms(ctx context.Context, examID primitive.Obje
 	return s.repo.GetExamRooms(ctx, examID)
 }
 
-// GetSeatingPlansByStudentID returns seating plans where a seat.student_id matches the given StudentID
+// GetSeatingPlansByStudentID returns published seating plans where a seat.student_id matches the given StudentID
 func (s *SeatingService) GetSeatingPlansByStudentID(ctx context.Context, studentID string) ([]*SeatingPlan, error) {
-	return s.repo.FindSeatingPlansByStudentID(ctx, studentID)
+	plans, err := s.repo.FindSeatingPlansByStudentID(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	// Students only ever see the current version of plans that went through approval, and
+	// only their own exam of a session
+	published := make([]*SeatingPlan, 0, len(plans))
+	for _, plan := range plans {
+		if plan.Status != PlanStatusPublished || plan.SupersededBy != nil {
+			continue
+		}
+		if len(plan.SessionExamIDs) > 0 {
+			plan = studentExamView(plan, studentID)
+		}
+		published = append(published, plan)
+	}
+	return published, nil
 }
 
 func (s *SeatingService) DeleteRoom(ctx context.Context, roomID primitive.ObjectID) error {
 	return s.repo.DeleteRoom(ctx, roomID)
 }
 
+// CreateRoom validates a new room's layout, derives its capacity and saves it.
+func (s *SeatingService) CreateRoom(ctx context.Context, room *Room) error {
+	if err := ValidateLayout(room.Rows, room.Columns, room.Layout); err != nil {
+		return err
+	}
+	room.Layout = normalizeLayout(room.Rows, room.Columns, room.Layout)
+	room.Capacity = room.SeatableCount()
+	return s.repo.CreateRoom(ctx, room)
+}
+
+// UpdateRoom updates a room. When no layout is supplied the existing one is kept,
+// trimmed to the new dimensions; capacity is always derived from the layout.
 func (s *SeatingService) UpdateRoom(ctx context.Context, roomID primitive.ObjectID, room *Room) error {
+	existing, err := s.repo.FindRoomByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("room not found")
+	}
+	if room.Layout == nil {
+		room.Layout = existing.Layout
+	} else if err := ValidateLayout(room.Rows, room.Columns, room.Layout); err != nil {
+		return err
+	}
+	if room.Rows <= 0 || room.Columns <= 0 {
+		return fmt.Errorf("%w: rows and columns must be positive", ErrInvalidLayout)
+	}
+	room.Layout = normalizeLayout(room.Rows, room.Columns, room.Layout)
+	room.Capacity = room.SeatableCount()
 	return s.repo.UpdateRoom(ctx, roomID, room)
 }
+
+// GetRoom retrieves a room by ID, returning an error when it does not exist.
+func (s *SeatingService) GetRoom(ctx context.Context, roomID primitive.ObjectID) (*Room, error) {
+	room, err := s.repo.FindRoomByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room == nil {
+		return nil, errors.New("room not found")
+	}
+	return room, nil
+}
+
+// SetRoomLayout replaces the whole layout of a room, optionally resizing its grid.
+// Zero rows or columns keep the current dimensions.
+func (s *SeatingService) SetRoomLayout(ctx context.Context, roomID primitive.ObjectID, rows, columns int, cells []LayoutCell) (*Room, error) {
+	room, err := s.GetRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if rows > 0 {
+		room.Rows = rows
+	}
+	if columns > 0 {
+		room.Columns = columns
+	}
+	if err := ValidateLayout(room.Rows, room.Columns, cells); err != nil {
+		return nil, err
+	}
+	room.Layout = normalizeLayout(room.Rows, room.Columns, cells)
+	room.Capacity = room.SeatableCount()
+	if err := s.repo.UpdateRoomLayout(ctx, roomID, room.Rows, room.Columns, room.Layout, room.Capacity); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// UpdateRoomLayoutCells changes the type of individual cells, leaving the rest of the layout untouched.
+func (s *SeatingService) UpdateRoomLayoutCells(ctx context.Context, roomID primitive.ObjectID, cells []LayoutCell) (*Room, error) {
+	room, err := s.GetRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateLayout(room.Rows, room.Columns, cells); err != nil {
+		return nil, err
+	}
+	merged := make(map[[2]int]LayoutCell)
+	var order [][2]int
+	for _, cell := range append(room.Layout, cells...) {
+		key := [2]int{cell.Row, cell.Column}
+		if _, ok := merged[key]; !ok {
+			order = append(order, key)
+		}
+		merged[key] = cell
+	}
+	layout := make([]LayoutCell, 0, len(order))
+	for _, key := range order {
+		layout = append(layout, merged[key])
+	}
+	return s.SetRoomLayout(ctx, roomID, 0, 0, layout)
+}
+
+// ResetRoomLayout marks every cell of a room as a usable seat.
+func (s *SeatingService) ResetRoomLayout(ctx context.Context, roomID primitive.ObjectID) (*Room, error) {
+	return s.SetRoomLayout(ctx, roomID, 0, 0, nil)
+}
@@ -0,0 +1,225 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidSession is wrapped when the exams given for a session plan cannot share rooms.
+var ErrInvalidSession = errors.New("invalid exam session")
+
+// examsOverlap reports whether two exams run at the same time.
+func examsOverlap(a, b *Exam) bool {
+	return periodsOverlap(a.Date, examEnd(a), b.Date, examEnd(b))
+}
+
+// sessionExamIDs returns the exams seated together with exam, exam first. Every other
+// exam must exist and run at the same time as exam.
+func (s *SeatingService) sessionExamIDs(ctx context.Context, exam *Exam, others []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	ids := []primitive.ObjectID{exam.ID}
+	seen := map[primitive.ObjectID]bool{exam.ID: true}
+	for _, id := range others {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		other, err := s.repo.FindExamByID(ctx, id)
+		if err != nil || other == nil {
+			return nil, fmt.Errorf("%w: exam %s not found", ErrInvalidSession, id.Hex())
+		}
+		if !examsOverlap(exam, other) {
+			return nil, fmt.Errorf("%w: exam %q does not run at the same time as %q", ErrInvalidSession, other.Title, exam.Title)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// sessionExamRooms returns the room assignments of all exams in a session, merged per
+// physical room so that each room lists the student lists and invigilators of every
+// exam held in it. It also maps each student list to the exam it was assigned for.
+// A single exam's assignments are returned unchanged, with a nil map.
+func (s *SeatingService) sessionExamRooms(ctx context.Context, examIDs []primitive.ObjectID) ([]*ExamRoom, map[primitive.ObjectID]primitive.ObjectID, error) {
+	if len(examIDs) == 1 {
+		examRooms, err := s.repo.GetExamRooms(ctx, examIDs[0])
+		return examRooms, nil, err
+	}
+	var merged []*ExamRoom
+	byRoom := make(map[primitive.ObjectID]*ExamRoom)
+	listExams := make(map[primitive.ObjectID]primitive.ObjectID)
+	for _, examID := range examIDs {
+		examRooms, err := s.repo.GetExamRooms(ctx, examID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, examRoom := range examRooms {
+			for _, id := range examRoom.StudentListIDs {
+				if _, ok := listExams[id]; !ok {
+					listExams[id] = examID
+				}
+			}
+			shared, ok := byRoom[examRoom.RoomID]
+			if !ok {
+				copied := *examRoom
+				copied.StudentListIDs = append([]primitive.ObjectID(nil), examRoom.StudentListIDs...)
+				copied.Invigilators = append([]primitive.ObjectID(nil), examRoom.Invigilators...)
+				copied.Roles = append([]RoomRole(nil), examRoom.Roles...)
+				byRoom[examRoom.RoomID] = &copied
+				merged = append(merged, &copied)
+				continue
+			}
+			shared.StudentListIDs = append(shared.StudentListIDs, examRoom.StudentListIDs...)
+			for _, inv := range examRoom.Invigilators {
+				if !containsObjectID(shared.Invigilators, inv) {
+					shared.Invigilators = append(shared.Invigilators, inv)
+					shared.Roles = append(shared.Roles, RoomRole{InvigilatorID: inv, Role: invigilatorRole(examRoom, inv)})
+				}
+			}
+		}
+	}
+	return merged, listExams, nil
+}
+
+// containsObjectID reports whether id is in ids.
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// examStudentsFromLists is studentsFromLists with every student tagged with the exam
+// their list was assigned for. A nil map leaves students untagged.
+func examStudentsFromLists(lists []*StudentList, listExams map[primitive.ObjectID]primitive.ObjectID) []StudentWithGroup {
+	if listExams == nil {
+		return studentsFromLists(lists)
+	}
+	var students []StudentWithGroup
+	for _, list := range lists {
+		for _, st := range studentsFromLists([]*StudentList{list}) {
+			st.ExamID = listExams[list.ID]
+			students = append(students, st)
+		}
+	}
+	return students
+}
+
+// sessionOnly returns examIDs when they form a session of several exams, and nil otherwise.
+func sessionOnly(examIDs []primitive.ObjectID) []primitive.ObjectID {
+	if len(examIDs) < 2 {
+		return nil
+	}
+	return examIDs
+}
+
+// sessionOthers returns the exams of a session plan besides its own exam.
+func sessionOthers(plan *SeatingPlan) []primitive.ObjectID {
+	if len(plan.SessionExamIDs) < 2 {
+		return nil
+	}
+	return plan.SessionExamIDs[1:]
+}
+
+// planExamIDs returns every exam seated in a plan.
+func planExamIDs(plan *SeatingPlan) []primitive.ObjectID {
+	if len(plan.SessionExamIDs) > 0 {
+		return plan.SessionExamIDs
+	}
+	return []primitive.ObjectID{plan.ExamID}
+}
+
+// GenerateSessionPlan seats the students of several concurrent exams together in their
+// shared rooms, separating them by exam so that neighbours sit different papers. The
+// combined plan belongs to the first exam and is versioned with its plans.
+func (s *SeatingService) GenerateSessionPlan(ctx context.Context, examIDs []primitive.ObjectID, opts GenerateOptions) (*SeatingPlan, error) {
+	if len(examIDs) < 2 {
+		return nil, fmt.Errorf("%w: a session plan needs at least two exams", ErrInvalidSession)
+	}
+	opts.SessionExams = examIDs[1:]
+	opts.Grouping = GroupByExam
+	plan, err := s.buildSeatingPlan(ctx, examIDs[0], opts)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.latestPlanVersion(ctx, examIDs[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveNewVersion(ctx, plan, previous); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// sessionExamView returns the part of a session plan that concerns one exam: seats of
+// other exams are shown empty, and rooms without any of its students are left out.
+// Scores and violations stay those of the combined plan.
+func sessionExamView(plan *SeatingPlan, examID primitive.ObjectID) *SeatingPlan {
+	view := *plan
+	view.ExamID = examID
+	view.Rooms = make([]SeatingPlanRoom, 0, len(plan.Rooms))
+	for _, room := range plan.Rooms {
+		seats := make([]Seat, len(room.Seats))
+		seated := 0
+		for i, seat := range room.Seats {
+			if !seat.IsEmpty && (seat.ExamID == nil || *seat.ExamID != examID) {
+				seat = Seat{Row: seat.Row, Column: seat.Column, IsEmpty: true, CellType: seat.CellType}
+			}
+			if !seat.IsEmpty {
+				seated++
+			}
+			seats[i] = seat
+		}
+		if seated == 0 {
+			continue
+		}
+		room.Seats = seats
+		room.VariantCounts = countPaperVariants(seats)
+		view.Rooms = append(view.Rooms, room)
+	}
+	view.UnplacedStudents = make([]UnplacedStudent, 0)
+	for _, st := range plan.UnplacedStudents {
+		if st.ExamID != nil && *st.ExamID == examID {
+			view.UnplacedStudents = append(view.UnplacedStudents, st)
+		}
+	}
+	return &view
+}
+
+// studentExamView returns the view of a session plan for the exam the student sits.
+func studentExamView(plan *SeatingPlan, studentID string) *SeatingPlan {
+	for _, room := range plan.Rooms {
+		for _, seat := range room.Seats {
+			if !seat.IsEmpty && seat.StudentID == studentID && seat.ExamID != nil {
+				return sessionExamView(plan, *seat.ExamID)
+			}
+		}
+	}
+	return plan
+}
+
+// GetSessionExamView returns the per-exam view of a plan. For single-exam plans the
+// exam must be the plan's own, and the plan is returned as it is.
+func (s *SeatingService) GetSessionExamView(ctx context.Context, planID, examID primitive.ObjectID) (*SeatingPlan, error) {
+	plan, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, errors.New("seating plan not found")
+	}
+	if !containsObjectID(planExamIDs(plan), examID) {
+		return nil, errors.New("exam is not part of this plan")
+	}
+	if len(plan.SessionExamIDs) == 0 {
+		return plan, nil
+	}
+	return sessionExamView(plan, examID), nil
+}
+
+// Why: Two small exams in one hall are safer interleaved than split into halves; seating them as one plan keyed by exam, with per-exam views, keeps each exam's paperwork separate.
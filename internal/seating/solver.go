@@ -0,0 +1,366 @@
+package seating
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// neighbourOffsets lists the 8 surrounding cells (orthogonal and diagonal).
+var neighbourOffsets = [8][2]int{
+	{-1, -1}, {-1, 0}, {-1, 1},
+	{0, -1}, {0, 1},
+	{1, -1}, {1, 0}, {1, 1},
+}
+
+// cellEmpty marks a solver grid cell that holds no student.
+const cellEmpty = -1
+
+// solverClass groups interchangeable students (same separation group).
+type solverClass struct {
+	group    string
+	students []StudentWithGroup
+}
+
+// seatingSolver places students on a room grid so that no two neighbours
+// (including diagonals) share a group. It first tries a
+// randomized backtracking search and, if that fails within its budget,
+// falls back to local search that minimizes the number of violations.
+type seatingSolver struct {
+	rows, cols int
+	seatable   []bool
+	classes    []solverClass
+	conflict   [][]bool
+	rng        *rand.Rand
+
+	maxRestarts     int
+	maxSteps        int
+	localIterations int
+
+	// search state
+	grid      []int
+	remaining []int
+	steps     int
+}
+
+// newSeatingSolver builds a solver for the given room and students.
+func newSeatingSolver(room *Room, students []StudentWithGroup, params AlgorithmParams, rng *rand.Rand) *seatingSolver {
+	index := map[string]int{}
+	var classes []solverClass
+	for _, st := range students {
+		i, ok := index[st.Group]
+		if !ok {
+			i = len(classes)
+			index[st.Group] = i
+			classes = append(classes, solverClass{group: st.Group})
+		}
+		classes[i].students = append(classes[i].students, st)
+	}
+	conflict := make([][]bool, len(classes))
+	for i := range classes {
+		conflict[i] = make([]bool, len(classes))
+		for j := range classes {
+			conflict[i][j] = classesConflict(classes[i], classes[j])
+		}
+	}
+	return &seatingSolver{
+		rows:            room.Rows,
+		cols:            room.Columns,
+		seatable:        room.seatableMask(),
+		classes:         classes,
+		conflict:        conflict,
+		rng:             rng,
+		maxRestarts:     params.Int("max_restarts", 20),
+		maxSteps:        params.Int("max_steps", 20000),
+		localIterations: params.Int("local_search_iterations", 20000),
+	}
+}
+
+// classesConflict reports whether two student classes may not sit next to each other.
+func classesConflict(a, b solverClass) bool {
+	return groupsConflict(a.group, b.group)
+}
+
+// Solve returns the class index placed in every cell (cellEmpty for an empty seat)
+// together with the number of adjacent conflicting pairs in the result.
+func (sv *seatingSolver) Solve() ([]int, int) {
+	for restart := 0; restart < sv.maxRestarts; restart++ {
+		sv.reset()
+		sv.steps = 0
+		if sv.backtrack(0, sv.seatableCount()-sv.studentCount()) {
+			return append([]int(nil), sv.grid...), 0
+		}
+	}
+	return sv.minimizeViolations()
+}
+
+func (sv *seatingSolver) cellCount() int { return sv.rows * sv.cols }
+
+func (sv *seatingSolver) seatableCount() int {
+	n := 0
+	for _, ok := range sv.seatable {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+func (sv *seatingSolver) studentCount() int {
+	n := 0
+	for _, c := range sv.classes {
+		n += len(c.students)
+	}
+	return n
+}
+
+func (sv *seatingSolver) reset() {
+	sv.grid = make([]int, sv.cellCount())
+	for i := range sv.grid {
+		sv.grid[i] = cellEmpty
+	}
+	sv.remaining = make([]int, len(sv.classes))
+	for i, c := range sv.classes {
+		sv.remaining[i] = len(c.students)
+	}
+}
+
+// backtrack fills cells in row-major order. Only the already-filled neighbours
+// (upper row and left) need checking, since later cells check back on us.
+// Unseatable cells stay empty without using up the empty-seat allowance.
+func (sv *seatingSolver) backtrack(pos, emptiesLeft int) bool {
+	if pos == sv.cellCount() {
+		return true
+	}
+	if !sv.seatable[pos] {
+		return sv.backtrack(pos+1, emptiesLeft)
+	}
+	sv.steps++
+	if sv.steps > sv.maxSteps {
+		return false
+	}
+	r, c := pos/sv.cols, pos%sv.cols
+	for _, class := range sv.candidateOrder() {
+		if sv.remaining[class] == 0 || sv.conflictsWithPlaced(r, c, class) {
+			continue
+		}
+		sv.grid[pos] = class
+		sv.remaining[class]--
+		if sv.backtrack(pos+1, emptiesLeft) {
+			return true
+		}
+		sv.remaining[class]++
+		sv.grid[pos] = cellEmpty
+		if sv.steps > sv.maxSteps {
+			return false
+		}
+	}
+	if emptiesLeft > 0 {
+		sv.grid[pos] = cellEmpty
+		return sv.backtrack(pos+1, emptiesLeft-1)
+	}
+	return false
+}
+
+// candidateOrder tries the largest remaining classes first, breaking ties randomly
+// so that each restart explores a different part of the search space.
+func (sv *seatingSolver) candidateOrder() []int {
+	order := sv.rng.Perm(len(sv.classes))
+	sort.SliceStable(order, func(i, j int) bool {
+		return sv.remaining[order[i]] > sv.remaining[order[j]]
+	})
+	return order
+}
+
+func (sv *seatingSolver) conflictsWithPlaced(r, c, class int) bool {
+	for _, off := range [4][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}} {
+		nr, nc := r+off[0], c+off[1]
+		if nr < 0 || nc < 0 || nc >= sv.cols {
+			continue
+		}
+		other := sv.grid[nr*sv.cols+nc]
+		if other >= 0 && sv.conflict[class][other] {
+			return true
+		}
+	}
+	return false
+}
+
+// localViolations counts conflicting neighbours of a single cell.
+func (sv *seatingSolver) localViolations(grid []int, pos int) int {
+	class := grid[pos]
+	if class < 0 {
+		return 0
+	}
+	r, c := pos/sv.cols, pos%sv.cols
+	count := 0
+	for _, off := range neighbourOffsets {
+		nr, nc := r+off[0], c+off[1]
+		if nr < 0 || nc < 0 || nr >= sv.rows || nc >= sv.cols {
+			continue
+		}
+		other := grid[nr*sv.cols+nc]
+		if other >= 0 && sv.conflict[class][other] {
+			count++
+		}
+	}
+	return count
+}
+
+func (sv *seatingSolver) totalViolations(grid []int) int {
+	total := 0
+	for pos := range grid {
+		total += sv.localViolations(grid, pos)
+	}
+	return total / 2
+}
+
+// minimizeViolations runs hill-climbing with random swaps from a greedy start,
+// restarting several times and keeping the best arrangement found.
+func (sv *seatingSolver) minimizeViolations() ([]int, int) {
+	var best []int
+	bestScore := -1
+	var cells []int
+	for pos, ok := range sv.seatable {
+		if ok {
+			cells = append(cells, pos)
+		}
+	}
+	restarts := sv.maxRestarts
+	if restarts < 1 {
+		restarts = 1
+	}
+	for restart := 0; restart < restarts && bestScore != 0; restart++ {
+		grid := sv.greedyStart()
+		score := sv.totalViolations(grid)
+		for it := 0; it < sv.localIterations && score > 0 && len(cells) > 1; it++ {
+			i := cells[sv.rng.Intn(len(cells))]
+			j := cells[sv.rng.Intn(len(cells))]
+			if i == j || grid[i] == grid[j] {
+				continue
+			}
+			before := sv.localViolations(grid, i) + sv.localViolations(grid, j)
+			grid[i], grid[j] = grid[j], grid[i]
+			after := sv.localViolations(grid, i) + sv.localViolations(grid, j)
+			if after > before {
+				grid[i], grid[j] = grid[j], grid[i]
+				continue
+			}
+			score += after - before
+		}
+		if bestScore < 0 || score < bestScore {
+			best = append([]int(nil), grid...)
+			bestScore = score
+		}
+	}
+	return best, sv.totalViolations(best)
+}
+
+// greedyStart places each cell's least-conflicting class, spreading empty seats
+// evenly so that they can act as separators.
+func (sv *seatingSolver) greedyStart() []int {
+	sv.reset()
+	emptiesLeft := sv.seatableCount() - sv.studentCount()
+	seatableLeft := sv.seatableCount()
+	for pos := range sv.grid {
+		if !sv.seatable[pos] {
+			continue
+		}
+		r, c := pos/sv.cols, pos%sv.cols
+		bestClass, bestConflicts := cellEmpty, -1
+		for _, class := range sv.candidateOrder() {
+			if sv.remaining[class] == 0 {
+				continue
+			}
+			conflicts := 0
+			if sv.conflictsWithPlaced(r, c, class) {
+				conflicts = 1
+			}
+			if bestConflicts < 0 || conflicts < bestConflicts {
+				bestClass, bestConflicts = class, conflicts
+			}
+		}
+		mustPlace := seatableLeft <= sv.studentCount()-sv.placedCount()
+		seatableLeft--
+		if bestClass == cellEmpty || (bestConflicts > 0 && emptiesLeft > 0 && !mustPlace) {
+			emptiesLeft--
+			continue
+		}
+		sv.grid[pos] = bestClass
+		sv.remaining[bestClass]--
+	}
+	return append([]int(nil), sv.grid...)
+}
+
+func (sv *seatingSolver) placedCount() int {
+	placed := sv.studentCount()
+	for _, n := range sv.remaining {
+		placed -= n
+	}
+	return placed
+}
+
+// seatsFromGrid converts a solved grid into seat assignments on top of the room's empty seats.
+func (sv *seatingSolver) seatsFromGrid(room *Room, grid []int) []Seat {
+	queues := make([][]StudentWithGroup, len(sv.classes))
+	for i, c := range sv.classes {
+		queues[i] = c.students
+	}
+	seats := emptySeats(room)
+	for pos, class := range grid {
+		if class >= 0 && len(queues[class]) > 0 {
+			seats[pos].StudentID = queues[class][0].StudentID
+			seats[pos].IsEmpty = false
+			queues[class] = queues[class][1:]
+		}
+	}
+	return seats
+}
+
+// solveSeating runs the constraint solver for a room.
+func solveSeating(room *Room, students []StudentWithGroup, opts ArrangeOptions) ([]Seat, error) {
+	if capacity := room.SeatableCount(); len(students) > capacity {
+		return nil, fmt.Errorf("room %s has %d seats but %d students were assigned", room.Name, capacity, len(students))
+	}
+	rng := opts.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	solver := newSeatingSolver(room, students, opts.Params, rng)
+	grid, _ := solver.Solve()
+	return solver.seatsFromGrid(room, grid), nil
+}
+
+// countSeatViolations counts neighbouring pairs (8-neighbourhood) whose groups
+// conflict.
+func countSeatViolations(seats []Seat, students []StudentWithGroup) int {
+	byID := make(map[string]StudentWithGroup, len(students))
+	for _, st := range students {
+		byID[st.StudentID] = st
+	}
+	grid := make(map[[2]int]StudentWithGroup, len(seats))
+	for _, seat := range seats {
+		if seat.IsEmpty || seat.StudentID == "" {
+			continue
+		}
+		if st, ok := byID[seat.StudentID]; ok {
+			grid[[2]int{seat.Row, seat.Column}] = st
+		}
+	}
+	count := 0
+	for pos, st := range grid {
+		for _, off := range neighbourOffsets {
+			other, ok := grid[[2]int{pos[0] + off[0], pos[1] + off[1]}]
+			if !ok {
+				continue
+			}
+			if groupsConflict(st.Group, other.Group) {
+				count++
+			}
+		}
+	}
+	return count / 2
+}
+
+// Why: A greedy pass over two neighbours gave up on rooms that had valid arrangements; searching with a budget and falling back to the least-bad layout always yields a plan plus an honest violation count.
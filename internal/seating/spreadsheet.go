@@ -0,0 +1,282 @@
+package seating
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Spreadsheet formats accepted for student list imports.
+const (
+	SpreadsheetCSV  = "csv"
+	SpreadsheetXLSX = "xlsx"
+)
+
+// Limits on what an import reads: Excel's last column is XFD, and no student list comes
+// near the row cap.
+const (
+	maxSpreadsheetColumns = 16384
+	maxSpreadsheetRows    = 100000
+)
+
+// spreadsheetRow is one non-blank row of an uploaded spreadsheet, with the line or row
+// number the user sees in their editor.
+type spreadsheetRow struct {
+	Line  int
+	Cells []string
+}
+
+// spreadsheetFormat picks the format from the file name, falling back to the content:
+// XLSX files are zip archives.
+func spreadsheetFormat(filename string, data []byte) (string, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return SpreadsheetCSV, nil
+	case ".xlsx":
+		return SpreadsheetXLSX, nil
+	case ".xls":
+		return "", errors.New("legacy .xls files are not supported, save the sheet as .xlsx or .csv")
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return SpreadsheetXLSX, nil
+	}
+	return SpreadsheetCSV, nil
+}
+
+// readSpreadsheet returns the non-blank rows of a CSV file or of one sheet of an XLSX
+// workbook; an empty sheet name means the first sheet.
+func readSpreadsheet(format string, data []byte, sheet string) ([]spreadsheetRow, error) {
+	switch format {
+	case SpreadsheetCSV:
+		return readCSV(data)
+	case SpreadsheetXLSX:
+		return readXLSX(data, sheet)
+	}
+	return nil, fmt.Errorf("unsupported spreadsheet format %q", format)
+}
+
+// readCSV parses comma-separated data, tolerating a UTF-8 byte order mark and rows of
+// differing length.
+func readCSV(data []byte) ([]spreadsheetRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var rows []spreadsheetRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if !blankRow(record) {
+			if len(rows) == maxSpreadsheetRows {
+				return nil, fmt.Errorf("more than %d rows", maxSpreadsheetRows)
+			}
+			rows = append(rows, spreadsheetRow{Line: line, Cells: record})
+		}
+	}
+	return rows, nil
+}
+
+// blankRow reports whether every cell of a row is empty.
+func blankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Minimal views of the SpreadsheetML parts needed to read cell values.
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var text strings.Builder
+	for _, run := range t.Runs {
+		text.WriteString(run.T)
+	}
+	return text.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX reads the cell values of one worksheet. Formulas are read as their cached
+// results and dates as Excel serial numbers.
+func readXLSX(data []byte, sheet string) ([]spreadsheetRow, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %v", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	decode := func(name string, v interface{}) error {
+		file, ok := files[name]
+		if !ok {
+			return fmt.Errorf("invalid XLSX file: missing %s", name)
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if err := xml.NewDecoder(rc).Decode(v); err != nil {
+			return fmt.Errorf("invalid XLSX file: %s: %v", name, err)
+		}
+		return nil
+	}
+
+	var workbook xlsxWorkbook
+	if err := decode("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errors.New("workbook has no sheets")
+	}
+	rid := ""
+	for _, s := range workbook.Sheets {
+		if sheet == "" || strings.EqualFold(s.Name, sheet) {
+			rid = s.RID
+			break
+		}
+	}
+	if rid == "" {
+		return nil, fmt.Errorf("sheet %q not found", sheet)
+	}
+	var rels xlsxRelationships
+	if err := decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == rid {
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPath = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPath = path.Join("xl", rel.Target)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, errors.New("invalid XLSX file: sheet has no part")
+	}
+
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decode("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	var ws xlsxSheet
+	if err := decode(sheetPath, &ws); err != nil {
+		return nil, err
+	}
+
+	var rows []spreadsheetRow
+	for i, row := range ws.Rows {
+		line := row.R
+		if line == 0 {
+			line = i + 1
+		}
+		var cells []string
+		for j, cell := range row.Cells {
+			column := xlsxColumn(cell.Ref)
+			if column < 0 {
+				column = j // Reference omitted; cells follow each other
+			}
+			if column >= maxSpreadsheetColumns {
+				return nil, fmt.Errorf("invalid XLSX file: cell %s is beyond column XFD", cell.Ref)
+			}
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("invalid XLSX file: bad shared string in cell %s", cell.Ref)
+				}
+				value = shared.Items[index].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			}
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+			cells[column] = value
+		}
+		if !blankRow(cells) {
+			if len(rows) == maxSpreadsheetRows {
+				return nil, fmt.Errorf("more than %d rows", maxSpreadsheetRows)
+			}
+			rows = append(rows, spreadsheetRow{Line: line, Cells: cells})
+		}
+	}
+	return rows, nil
+}
+
+// xlsxColumn returns the 0-based column of a cell reference such as "AB12", or -1
+// when the reference has no column letters. References past the last column return
+// maxSpreadsheetColumns instead of overflowing.
+func xlsxColumn(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		if column > maxSpreadsheetColumns {
+			return maxSpreadsheetColumns
+		}
+	}
+	return column - 1
+}
+
+// Why: Registrar exports arrive as spreadsheets; reading CSV and the XLSX zip/XML parts directly avoids a hand conversion step without pulling in a spreadsheet dependency.
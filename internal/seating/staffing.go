@@ -0,0 +1,291 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invigilator roles within an exam room.
+const (
+	InvigilatorRoleChief           = "chief"            // In charge of the room; one per room
+	InvigilatorRoleAssistant       = "assistant"        // Supports the chief
+	InvigilatorRoleRelief          = "relief"           // Covers breaks; does not count towards minimum staffing
+	InvigilatorRoleFloorSupervisor = "floor_supervisor" // Oversees several rooms of one building at once
+)
+
+// ErrInvalidAssignment is wrapped when an invigilator cannot take a role in a room.
+var ErrInvalidAssignment = errors.New("invalid invigilator assignment")
+
+// ErrUnderstaffed is wrapped when a plan is published while rooms lack required staff.
+var ErrUnderstaffed = errors.New("rooms are not fully staffed")
+
+// IsValidInvigilatorRole reports whether role is a known invigilator role.
+func IsValidInvigilatorRole(role string) bool {
+	switch role {
+	case InvigilatorRoleChief, InvigilatorRoleAssistant, InvigilatorRoleRelief, InvigilatorRoleFloorSupervisor:
+		return true
+	}
+	return false
+}
+
+// invigilatorRole returns the role of an invigilator in an exam room. Invigilators
+// assigned before roles existed count as assistants.
+func invigilatorRole(examRoom *ExamRoom, invigilatorID primitive.ObjectID) string {
+	for _, role := range examRoom.Roles {
+		if role.InvigilatorID == invigilatorID {
+			return role.Role
+		}
+	}
+	return InvigilatorRoleAssistant
+}
+
+// StaffingRule is the minimum staff of a room seating at least MinStudents students.
+type StaffingRule struct {
+	MinStudents int `json:"min_students"`
+	Chiefs      int `json:"chiefs"`
+	Assistants  int `json:"assistants"`
+}
+
+// DefaultStaffingRules lists the minimum staff per room size, smallest rooms first.
+// Relief invigilators and floor supervisors come on top of these.
+var DefaultStaffingRules = []StaffingRule{
+	{MinStudents: 0, Chiefs: 1, Assistants: 0},
+	{MinStudents: 31, Chiefs: 1, Assistants: 1},
+	{MinStudents: 61, Chiefs: 1, Assistants: 2},
+	{MinStudents: 121, Chiefs: 1, Assistants: 3},
+	{MinStudents: 201, Chiefs: 1, Assistants: 4},
+}
+
+// staffingRuleFor returns the rule for a room seating the given number of students.
+func staffingRuleFor(students int) StaffingRule {
+	rule := DefaultStaffingRules[0]
+	for _, candidate := range DefaultStaffingRules {
+		if students >= candidate.MinStudents {
+			rule = candidate
+		}
+	}
+	return rule
+}
+
+// RoomStaffing compares the staff of one physical room with its staffing rule.
+type RoomStaffing struct {
+	RoomID           primitive.ObjectID `json:"room_id"`
+	RoomName         string             `json:"room_name"`
+	Students         int                `json:"students"`
+	Required         StaffingRule       `json:"required"`
+	Chiefs           int                `json:"chiefs"`
+	Assistants       int                `json:"assistants"`
+	Relief           int                `json:"relief"`
+	FloorSupervisors int                `json:"floor_supervisors"`
+	Missing          string             `json:"missing,omitempty"` // What is short, e.g. "1 chief, 2 assistant(s)"; empty when fully staffed
+}
+
+// staffRoom counts the roles held across the assignments of one physical room, which
+// are several when exams share the room.
+func staffRoom(examRooms []*ExamRoom, students int) RoomStaffing {
+	staffing := RoomStaffing{Students: students, Required: staffingRuleFor(students)}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, examRoom := range examRooms {
+		for _, id := range examRoom.Invigilators {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			switch invigilatorRole(examRoom, id) {
+			case InvigilatorRoleChief:
+				staffing.Chiefs++
+			case InvigilatorRoleRelief:
+				staffing.Relief++
+			case InvigilatorRoleFloorSupervisor:
+				staffing.FloorSupervisors++
+			default:
+				staffing.Assistants++
+			}
+		}
+	}
+	var missing []string
+	if short := staffing.Required.Chiefs - staffing.Chiefs; short > 0 {
+		missing = append(missing, fmt.Sprintf("%d chief", short))
+	}
+	if short := staffing.Required.Assistants - staffing.Assistants; short > 0 {
+		missing = append(missing, fmt.Sprintf("%d assistant(s)", short))
+	}
+	staffing.Missing = strings.Join(missing, ", ")
+	return staffing
+}
+
+// checkRoleAssignment verifies that an invigilator may hold role in examRoom: a room has
+// at most one chief, and nobody covers two rooms of an exam except floor supervisors,
+// whose rooms must all be in the same building.
+func (s *SeatingService) checkRoleAssignment(ctx context.Context, examRoom *ExamRoom, invigilatorID primitive.ObjectID, role string) error {
+	if !IsValidInvigilatorRole(role) {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidAssignment, role)
+	}
+	if role == InvigilatorRoleChief {
+		for _, id := range examRoom.Invigilators {
+			if id != invigilatorID && invigilatorRole(examRoom, id) == InvigilatorRoleChief {
+				return fmt.Errorf("%w: room already has a chief invigilator", ErrInvalidAssignment)
+			}
+		}
+	}
+
+	examRooms, err := s.repo.GetExamRooms(ctx, examRoom.ExamID)
+	if err != nil {
+		return err
+	}
+	var building string
+	if role == InvigilatorRoleFloorSupervisor {
+		if room, err := s.repo.FindRoomByID(ctx, examRoom.RoomID); err == nil && room != nil {
+			building = room.Building
+		}
+	}
+	for _, other := range examRooms {
+		if other.ID == examRoom.ID || !containsObjectID(other.Invigilators, invigilatorID) {
+			continue
+		}
+		if role != InvigilatorRoleFloorSupervisor || invigilatorRole(other, invigilatorID) != InvigilatorRoleFloorSupervisor {
+			return fmt.Errorf("%w: invigilator is already assigned to another room in this exam", ErrInvalidAssignment)
+		}
+		room, err := s.repo.FindRoomByID(ctx, other.RoomID)
+		if err != nil {
+			return err
+		}
+		if room != nil && room.Building != building {
+			return fmt.Errorf("%w: floor supervisor already covers rooms in %s", ErrInvalidAssignment, room.Building)
+		}
+	}
+	return nil
+}
+
+// AssignInvigilator adds an invigilator to an exam room in the given role. Declared
+// unavailability fails the assignment unless override is set, in which case it is
+// returned as a warning.
+func (s *SeatingService) AssignInvigilator(ctx context.Context, examRoomID, invigilatorID primitive.ObjectID, role string, override bool) (string, error) {
+	examRoom, err := s.repo.FindExamRoomByID(ctx, examRoomID)
+	if err != nil {
+		return "", err
+	}
+	if examRoom == nil {
+		return "", errors.New("exam room not found")
+	}
+	if containsObjectID(examRoom.Invigilators, invigilatorID) {
+		return "", fmt.Errorf("%w: invigilator is already assigned to this room", ErrInvalidAssignment)
+	}
+	if err := s.checkRoleAssignment(ctx, examRoom, invigilatorID, role); err != nil {
+		return "", err
+	}
+
+	warning := ""
+	if err := s.CheckInvigilatorAvailable(ctx, examRoom.ExamID, invigilatorID); err != nil {
+		if !errors.Is(err, ErrInvigilatorUnavailable) || !override {
+			return "", err
+		}
+		warning = err.Error()
+	}
+	if err := s.repo.AddInvigilatorToRoom(ctx, examRoomID, invigilatorID, role); err != nil {
+		if err.Error() == "invigilator already assigned to this room" {
+			return "", fmt.Errorf("%w: %v", ErrInvalidAssignment, err)
+		}
+		return "", err
+	}
+	return warning, nil
+}
+
+// SetInvigilatorRole changes the role of an invigilator already assigned to an exam room.
+func (s *SeatingService) SetInvigilatorRole(ctx context.Context, examRoomID, invigilatorID primitive.ObjectID, role string) error {
+	examRoom, err := s.repo.FindExamRoomByID(ctx, examRoomID)
+	if err != nil {
+		return err
+	}
+	if examRoom == nil {
+		return errors.New("exam room not found")
+	}
+	if !containsObjectID(examRoom.Invigilators, invigilatorID) {
+		return errors.New("invigilator not assigned to this room")
+	}
+	if err := s.checkRoleAssignment(ctx, examRoom, invigilatorID, role); err != nil {
+		return err
+	}
+	return s.repo.SetInvigilatorRole(ctx, examRoomID, invigilatorID, role)
+}
+
+// GetExamStaffing reports the staffing of every room of an exam, sized by the students
+// on the lists assigned to each room.
+func (s *SeatingService) GetExamStaffing(ctx context.Context, examID primitive.ObjectID) ([]RoomStaffing, error) {
+	exam, err := s.repo.FindExamByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	if exam == nil {
+		return nil, errors.New("exam not found")
+	}
+	examRooms, err := s.repo.GetExamRooms(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	report := []RoomStaffing{}
+	for _, examRoom := range examRooms {
+		students := 0
+		if len(examRoom.StudentListIDs) > 0 {
+			lists, err := s.repo.FindStudentListsByIDs(ctx, examRoom.StudentListIDs)
+			if err != nil {
+				return nil, err
+			}
+			students = len(studentsFromLists(lists))
+		}
+		staffing := staffRoom([]*ExamRoom{examRoom}, students)
+		staffing.RoomID, staffing.RoomName = examRoom.RoomID, examRoom.RoomID.Hex()
+		if room, err := s.repo.FindRoomByID(ctx, examRoom.RoomID); err == nil && room != nil {
+			staffing.RoomName = room.Name
+		}
+		report = append(report, staffing)
+	}
+	return report, nil
+}
+
+// checkPlanStaffed fails with ErrUnderstaffed, listing what is short, unless every
+// occupied room of the plan meets its staffing rule for the students seated in it.
+func (s *SeatingService) checkPlanStaffed(ctx context.Context, plan *SeatingPlan) error {
+	examIDs := plan.SessionExamIDs
+	if len(examIDs) == 0 {
+		examIDs = []primitive.ObjectID{plan.ExamID}
+	}
+	examRooms, _, err := s.sessionExamRooms(ctx, examIDs)
+	if err != nil {
+		return err
+	}
+	byRoom := make(map[primitive.ObjectID]*ExamRoom, len(examRooms))
+	for _, examRoom := range examRooms {
+		byRoom[examRoom.RoomID] = examRoom
+	}
+
+	var short []string
+	for _, planRoom := range plan.Rooms {
+		students := 0
+		for _, seat := range planRoom.Seats {
+			if seat.StudentID != "" {
+				students++
+			}
+		}
+		if students == 0 {
+			continue // Nobody sits here, so nobody needs to invigilate
+		}
+		var assigned []*ExamRoom
+		if examRoom, ok := byRoom[planRoom.RoomID]; ok {
+			assigned = append(assigned, examRoom)
+		}
+		if staffing := staffRoom(assigned, students); staffing.Missing != "" {
+			short = append(short, fmt.Sprintf("%s needs %s", planRoom.Name, staffing.Missing))
+		}
+	}
+	if len(short) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnderstaffed, strings.Join(short, "; "))
+	}
+	return nil
+}
+
+// Why: A flat invigilator list said nothing about who runs a room, so plans went out with rooms lacking a chief; tagged roles and per-size minimums make that visible and block publishing until it is fixed.
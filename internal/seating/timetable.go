@@ -0,0 +1,500 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultTimetableIterations bounds the improvement passes of the timetable solver.
+const defaultTimetableIterations = 50
+
+// ErrInvalidTimetable is wrapped when a timetabling problem is malformed.
+var ErrInvalidTimetable = errors.New("invalid timetable problem")
+
+// ErrTimetableIncomplete is returned when a timetable is applied while some exams found no slot.
+var ErrTimetableIncomplete = errors.New("some exams could not be scheduled")
+
+// TimePeriod is a span of time, e.g. when a room is already taken.
+type TimePeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// TimetableExam is an exam to schedule together with the students sitting it.
+type TimetableExam struct {
+	ID       primitive.ObjectID `json:"id"`
+	Title    string             `json:"title"`
+	Duration int                `json:"duration"` // Minutes
+	Students []string           `json:"students"` // Student IDs; duplicates are ignored
+}
+
+// TimetableRoom is a room exams may be booked into.
+type TimetableRoom struct {
+	ID       primitive.ObjectID `json:"id"`
+	Name     string             `json:"name"`
+	Building string             `json:"building"`
+	Capacity int                `json:"capacity"`       // Seats usable under the intended spacing rule
+	Busy     []TimePeriod       `json:"busy,omitempty"` // Periods the room is already booked or blocked
+}
+
+// TimetableProblem is the input of the timetable solver. It holds no database references,
+// so problems can be saved as JSON and solved offline.
+type TimetableProblem struct {
+	Exams      []TimetableExam `json:"exams"`
+	Slots      []time.Time     `json:"slots"` // Start times exams may be put at
+	Rooms      []TimetableRoom `json:"rooms"`
+	Iterations int             `json:"iterations,omitempty"` // Improvement passes; 0 means the default
+}
+
+// TimetableEntry is the slot and rooms chosen for one exam.
+type TimetableEntry struct {
+	ExamID   primitive.ObjectID   `json:"exam_id"`
+	Title    string               `json:"title"`
+	Start    time.Time            `json:"start"`
+	End      time.Time            `json:"end"`
+	RoomIDs  []primitive.ObjectID `json:"room_ids"`
+	Students int                  `json:"students"`
+	Capacity int                  `json:"capacity"`
+}
+
+// UnscheduledExam is an exam the solver found no clash-free slot with enough rooms for.
+type UnscheduledExam struct {
+	ExamID primitive.ObjectID `json:"exam_id"`
+	Title  string             `json:"title"`
+	Reason string             `json:"reason"`
+}
+
+// Timetable is a clash-free schedule: no student sits two exams at overlapping times.
+type Timetable struct {
+	Entries         []TimetableEntry  `json:"entries"` // Ordered by start time
+	Unscheduled     []UnscheduledExam `json:"unscheduled"`
+	SameDayStudents int               `json:"same_day_students"` // Students with two or more exams on one day
+	Warnings        []string          `json:"warnings"`
+	Applied         bool              `json:"applied"` // Exam dates and draft room bookings were written
+}
+
+// timetableSolver holds the working state of one SolveTimetable run.
+type timetableSolver struct {
+	problem TimetableProblem
+	slots   []time.Time
+	sizes   []int
+	shared  [][]int // Students two exams have in common
+	slot    []int   // Chosen slot per exam, -1 while unplaced
+	rooms   [][]int // Chosen rooms per exam, as indices into problem.Rooms
+}
+
+// window returns the period exam i occupies when started at slot s.
+func (t *timetableSolver) window(i, s int) (time.Time, time.Time) {
+	start := t.slots[s]
+	return start, start.Add(time.Duration(t.problem.Exams[i].Duration) * time.Minute)
+}
+
+// sameDay reports whether two times fall on the same calendar day.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// fit returns the rooms exam i would take at slot s, and false when the slot is not
+// possible: a student would sit two overlapping exams, or the free rooms are too small.
+func (t *timetableSolver) fit(i, s int) ([]int, bool) {
+	start, end := t.window(i, s)
+	used := make(map[int]bool)
+	for j := range t.problem.Exams {
+		if j == i || t.slot[j] < 0 {
+			continue
+		}
+		otherStart, otherEnd := t.window(j, t.slot[j])
+		if !periodsOverlap(start, end, otherStart, otherEnd) {
+			continue
+		}
+		if t.shared[i][j] > 0 {
+			return nil, false
+		}
+		for _, r := range t.rooms[j] {
+			used[r] = true
+		}
+	}
+	if t.sizes[i] == 0 {
+		return nil, true
+	}
+	var free []*Room
+	index := make(map[primitive.ObjectID]int)
+	for r, room := range t.problem.Rooms {
+		if used[r] || room.Capacity <= 0 {
+			continue
+		}
+		busy := false
+		for _, period := range room.Busy {
+			if periodsOverlap(start, end, period.Start, period.End) {
+				busy = true
+				break
+			}
+		}
+		if busy {
+			continue
+		}
+		free = append(free, &Room{ID: room.ID, Name: room.Name, Building: room.Building, Capacity: room.Capacity})
+		index[room.ID] = r
+	}
+	picked := chooseRooms(free, t.sizes[i], "")
+	if picked == nil {
+		return nil, false
+	}
+	rooms := make([]int, len(picked))
+	for k, room := range picked {
+		rooms[k] = index[room.ID]
+	}
+	return rooms, true
+}
+
+// cost counts the students exam i would share a day with other placed exams at slot s.
+func (t *timetableSolver) cost(i, s int) int {
+	total := 0
+	for j := range t.problem.Exams {
+		if j != i && t.slot[j] >= 0 && t.shared[i][j] > 0 && sameDay(t.slots[s], t.slots[t.slot[j]]) {
+			total += t.shared[i][j]
+		}
+	}
+	return total
+}
+
+// place puts exam i on its cheapest possible slot, keeping current when it is among the
+// cheapest, else the earliest. It reports whether the exam is placed afterwards.
+func (t *timetableSolver) place(i, current int) bool {
+	t.slot[i], t.rooms[i] = -1, nil
+	best, bestCost := -1, -1
+	var bestRooms []int
+	for s := range t.slots {
+		rooms, ok := t.fit(i, s)
+		if !ok {
+			continue
+		}
+		cost := t.cost(i, s)
+		if best < 0 || cost < bestCost || (cost == bestCost && s == current) {
+			best, bestCost, bestRooms = s, cost, rooms
+		}
+	}
+	if best < 0 {
+		return false
+	}
+	t.slot[i], t.rooms[i] = best, bestRooms
+	return true
+}
+
+// SolveTimetable schedules exams into slots and rooms so that no student has two exams at
+// overlapping times, while keeping as few students as possible on two exams in one day.
+// Exams clashing with many others and large exams are placed first, each on its cheapest
+// possible slot; improvement passes then move single exams while that lowers the cost.
+// Exams that fit nowhere are reported as unscheduled. The result is deterministic.
+func SolveTimetable(problem TimetableProblem) (*Timetable, error) {
+	if len(problem.Exams) == 0 {
+		return nil, fmt.Errorf("%w: no exams given", ErrInvalidTimetable)
+	}
+	if len(problem.Slots) == 0 {
+		return nil, fmt.Errorf("%w: no time slots given", ErrInvalidTimetable)
+	}
+	if problem.Iterations <= 0 {
+		problem.Iterations = defaultTimetableIterations
+	}
+	n := len(problem.Exams)
+	t := &timetableSolver{
+		problem: problem,
+		slots:   append([]time.Time(nil), problem.Slots...),
+		sizes:   make([]int, n),
+		shared:  make([][]int, n),
+		slot:    make([]int, n),
+		rooms:   make([][]int, n),
+	}
+	sort.Slice(t.slots, func(a, b int) bool { return t.slots[a].Before(t.slots[b]) })
+
+	seenExams := make(map[primitive.ObjectID]bool)
+	studentExams := make(map[string][]int)
+	for i, exam := range problem.Exams {
+		if seenExams[exam.ID] {
+			return nil, fmt.Errorf("%w: exam %s listed twice", ErrInvalidTimetable, exam.ID.Hex())
+		}
+		seenExams[exam.ID] = true
+		if exam.Duration <= 0 {
+			return nil, fmt.Errorf("%w: exam %q needs a positive duration", ErrInvalidTimetable, exam.Title)
+		}
+		seen := make(map[string]bool)
+		for _, id := range exam.Students {
+			if !seen[id] {
+				seen[id] = true
+				studentExams[id] = append(studentExams[id], i)
+			}
+		}
+		t.sizes[i] = len(seen)
+		t.shared[i] = make([]int, n)
+		t.slot[i] = -1
+	}
+	for _, exams := range studentExams {
+		for _, a := range exams {
+			for _, b := range exams {
+				if a != b {
+					t.shared[a][b]++
+				}
+			}
+		}
+	}
+
+	degree := make([]int, n)
+	for i := range t.shared {
+		for _, common := range t.shared[i] {
+			if common > 0 {
+				degree[i]++
+			}
+		}
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if degree[order[a]] != degree[order[b]] {
+			return degree[order[a]] > degree[order[b]]
+		}
+		return t.sizes[order[a]] > t.sizes[order[b]]
+	})
+	for _, i := range order {
+		t.place(i, -1)
+	}
+	for pass := 0; pass < problem.Iterations; pass++ {
+		changed := false
+		for _, i := range order {
+			before, beforeCost := t.slot[i], 0
+			if before >= 0 {
+				beforeCost = t.cost(i, before)
+			}
+			beforeRooms := t.rooms[i]
+			if !t.place(i, before) {
+				t.slot[i], t.rooms[i] = before, beforeRooms
+				continue
+			}
+			if before < 0 || t.cost(i, t.slot[i]) < beforeCost {
+				changed = true
+				continue
+			}
+			// No gain: keep the exam where it was, rooms included
+			t.slot[i], t.rooms[i] = before, beforeRooms
+		}
+		if !changed {
+			break
+		}
+	}
+	return t.timetable(studentExams), nil
+}
+
+// timetable turns the solver state into its result.
+func (t *timetableSolver) timetable(studentExams map[string][]int) *Timetable {
+	result := &Timetable{Entries: []TimetableEntry{}, Unscheduled: []UnscheduledExam{}, Warnings: []string{}}
+	for i, exam := range t.problem.Exams {
+		if t.slot[i] < 0 {
+			result.Unscheduled = append(result.Unscheduled, UnscheduledExam{
+				ExamID: exam.ID,
+				Title:  exam.Title,
+				Reason: "no slot without clashes has enough free rooms",
+			})
+			continue
+		}
+		start, end := t.window(i, t.slot[i])
+		entry := TimetableEntry{ExamID: exam.ID, Title: exam.Title, Start: start, End: end, RoomIDs: []primitive.ObjectID{}, Students: t.sizes[i]}
+		for _, r := range t.rooms[i] {
+			entry.RoomIDs = append(entry.RoomIDs, t.problem.Rooms[r].ID)
+			entry.Capacity += t.problem.Rooms[r].Capacity
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	sort.SliceStable(result.Entries, func(a, b int) bool { return result.Entries[a].Start.Before(result.Entries[b].Start) })
+	for _, exams := range studentExams {
+		found := false
+		for x := 0; x < len(exams) && !found; x++ {
+			for y := x + 1; y < len(exams) && !found; y++ {
+				a, b := exams[x], exams[y]
+				found = t.slot[a] >= 0 && t.slot[b] >= 0 && sameDay(t.slots[t.slot[a]], t.slots[t.slot[b]])
+			}
+		}
+		if found {
+			result.SameDayStudents++
+		}
+	}
+	return result
+}
+
+// TimetableExamInput names an exam to schedule and the student lists sitting it.
+type TimetableExamInput struct {
+	ExamID         primitive.ObjectID
+	StudentListIDs []primitive.ObjectID
+}
+
+// TimetableOptions configures a timetable run against the database.
+type TimetableOptions struct {
+	Exams      []TimetableExamInput
+	Slots      []time.Time
+	RoomIDs    []primitive.ObjectID // Rooms to book; empty means every room except separate rooms
+	Spacing    string               // Spacing rule room capacities are counted under
+	Iterations int
+	Apply      bool // Write exam dates and draft room bookings
+}
+
+// GenerateTimetable builds the timetabling problem for the given exams from their student
+// lists and the rooms' existing bookings, and solves it. When applying, every exam gets
+// its new date and a draft booking per chosen room carrying its student lists; exams must
+// not have rooms yet, and nothing is written unless every exam was scheduled.
+func (s *SeatingService) GenerateTimetable(ctx context.Context, opts TimetableOptions) (*Timetable, error) {
+	if !IsValidSpacing(opts.Spacing) {
+		return nil, fmt.Errorf("%w: invalid spacing %q", ErrInvalidTimetable, opts.Spacing)
+	}
+	problem := TimetableProblem{Slots: opts.Slots, Iterations: opts.Iterations}
+	exams := make(map[primitive.ObjectID]*Exam, len(opts.Exams))
+	examLists := make(map[primitive.ObjectID][]*StudentList, len(opts.Exams))
+	for _, input := range opts.Exams {
+		exam, err := s.repo.FindExamByID(ctx, input.ExamID)
+		if err != nil {
+			return nil, err
+		}
+		if exam == nil {
+			return nil, fmt.Errorf("%w: exam %s not found", ErrInvalidTimetable, input.ExamID.Hex())
+		}
+		if opts.Apply {
+			existing, err := s.repo.GetExamRooms(ctx, exam.ID)
+			if err != nil {
+				return nil, err
+			}
+			if len(existing) > 0 {
+				return nil, fmt.Errorf("%w: exam %q already has rooms assigned", ErrInvalidTimetable, exam.Title)
+			}
+		}
+		lists, err := s.repo.FindStudentListsByIDs(ctx, input.StudentListIDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(lists) != len(input.StudentListIDs) {
+			return nil, fmt.Errorf("%w: student list of exam %q not found", ErrInvalidTimetable, exam.Title)
+		}
+		lists = orderListsByIDs(lists, input.StudentListIDs)
+		exams[exam.ID], examLists[exam.ID] = exam, lists
+		var students []string
+		for _, st := range studentsFromLists(lists) {
+			students = append(students, st.StudentID)
+		}
+		problem.Exams = append(problem.Exams, TimetableExam{ID: exam.ID, Title: exam.Title, Duration: exam.Duration, Students: students})
+	}
+
+	var rooms []*Room
+	if len(opts.RoomIDs) == 0 {
+		all, err := s.repo.GetAllRooms(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, room := range all {
+			if !room.SeparateRoom {
+				rooms = append(rooms, room)
+			}
+		}
+	} else {
+		for _, id := range opts.RoomIDs {
+			room, err := s.repo.FindRoomByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if room == nil {
+				return nil, fmt.Errorf("%w: room %s not found", ErrInvalidTimetable, id.Hex())
+			}
+			rooms = append(rooms, room)
+		}
+	}
+	roomIDs := make([]primitive.ObjectID, len(rooms))
+	for i, room := range rooms {
+		roomIDs[i] = room.ID
+	}
+	bookings, err := s.roomBookings(ctx, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+	spaced := make(map[primitive.ObjectID]*Room, len(rooms))
+	for _, room := range rooms {
+		room = applySpacing(room, opts.Spacing)
+		spaced[room.ID] = room
+		timetableRoom := TimetableRoom{ID: room.ID, Name: room.Name, Building: room.Building, Capacity: room.Capacity}
+		for _, booking := range bookings[room.ID] {
+			// The exams being scheduled give up whatever time they had
+			if booking.ExamID != nil && exams[*booking.ExamID] != nil {
+				continue
+			}
+			timetableRoom.Busy = append(timetableRoom.Busy, TimePeriod{Start: booking.Start, End: booking.End})
+		}
+		problem.Rooms = append(problem.Rooms, timetableRoom)
+	}
+
+	timetable, err := SolveTimetable(problem)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Apply {
+		return timetable, nil
+	}
+	if len(timetable.Unscheduled) > 0 {
+		return timetable, ErrTimetableIncomplete
+	}
+
+	now := time.Now()
+	for _, entry := range timetable.Entries {
+		exam := exams[entry.ExamID]
+		exam.Date = entry.Start
+		exam.UpdatedAt = now
+		if err := s.repo.UpdateExam(ctx, exam); err != nil {
+			return nil, err
+		}
+		allocated := make([]AllocatedRoom, 0, len(entry.RoomIDs))
+		for _, id := range entry.RoomIDs {
+			allocated = append(allocated, AllocatedRoom{RoomID: id, Capacity: spaced[id].Capacity})
+		}
+		lists := examLists[entry.ExamID]
+		sizes := make(map[primitive.ObjectID]int, len(lists))
+		seen := make(map[string]bool)
+		for _, list := range lists {
+			for _, st := range list.Students {
+				if !seen[st.StudentID] {
+					seen[st.StudentID] = true
+					sizes[list.ID]++
+				}
+			}
+		}
+		if len(allocated) > 0 && !packLists(allocated, lists, sizes) {
+			spreadLists(allocated, lists, sizes)
+			timetable.Warnings = append(timetable.Warnings, fmt.Sprintf("student lists of exam %q do not fit a single room whole; generate it in pooled mode", exam.Title))
+		}
+		for _, room := range allocated {
+			examRoom := &ExamRoom{
+				ID:             primitive.NewObjectID(),
+				ExamID:         exam.ID,
+				RoomID:         room.RoomID,
+				StudentListIDs: room.StudentListIDs,
+				Invigilators:   []primitive.ObjectID{},
+				Draft:          true,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			}
+			if err := s.repo.CreateExamRoom(ctx, examRoom); err != nil {
+				return nil, err
+			}
+		}
+	}
+	timetable.Applied = true
+	return timetable, nil
+}
+
+// ConfirmExamRooms turns an exam's draft room bookings into regular ones.
+func (s *SeatingService) ConfirmExamRooms(ctx context.Context, examID primitive.ObjectID) (int64, error) {
+	return s.repo.ConfirmExamRooms(ctx, examID)
+}
+
+// Why: Hand-made timetables put one cohort into two halls at once; a solver that treats shared students as hard clashes and same-day pairs as cost, with draft bookings to review, removes the guesswork before seating starts.
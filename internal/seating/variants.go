@@ -0,0 +1,103 @@
+package seating
+
+// MaxPaperSets is the largest number of question-paper variants an exam may use.
+const MaxPaperSets = 6
+
+// paperVariantLabels returns the labels of an exam's paper variants (A, B, C, ...),
+// or nil when the exam uses a single paper.
+func paperVariantLabels(sets int) []string {
+	if sets < 2 {
+		return nil
+	}
+	if sets > MaxPaperSets {
+		sets = MaxPaperSets
+	}
+	labels := make([]string, sets)
+	for i := range labels {
+		labels[i] = string(rune('A' + i))
+	}
+	return labels
+}
+
+// patternVariant is the variant a seat gets on a fully occupied grid: a checkerboard
+// for two sets, otherwise a diagonal stripe that keeps all 8 neighbours apart from
+// four sets upwards.
+func patternVariant(row, col, sets int) int {
+	if sets == 2 {
+		return (row + col) % 2
+	}
+	return (col + 2*row) % sets
+}
+
+// assignPaperVariants gives every occupied seat without a variant the one least used by
+// its neighbours, side-by-side and front/back neighbours counting double, so that with
+// two or more sets no two adjacent students share a paper. Ties follow patternVariant,
+// then the least handed-out variant. Seats that already carry a valid variant keep it,
+// which lets manual edits change only the seats they touch. Empty seats never carry a
+// variant.
+func assignPaperVariants(seats []Seat, sets int) {
+	labels := paperVariantLabels(sets)
+	valid := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		valid[label] = true
+	}
+	assigned := make(map[[2]int]string)
+	used := make(map[string]int)
+	for i := range seats {
+		seat := &seats[i]
+		if seat.IsEmpty || seat.StudentID == "" || !valid[seat.Variant] {
+			seat.Variant = ""
+			continue
+		}
+		assigned[[2]int{seat.Row, seat.Column}] = seat.Variant
+		used[seat.Variant]++
+	}
+	if len(labels) == 0 {
+		return
+	}
+	for i := range seats {
+		seat := &seats[i]
+		if seat.IsEmpty || seat.StudentID == "" || seat.Variant != "" {
+			continue
+		}
+		best, bestCost := "", -1
+		preferred := labels[patternVariant(seat.Row, seat.Column, len(labels))]
+		for _, label := range labels {
+			cost := 0
+			for _, off := range neighbourOffsets {
+				if assigned[[2]int{seat.Row + off[0], seat.Column + off[1]}] != label {
+					continue
+				}
+				if off[0] == 0 || off[1] == 0 {
+					cost += 2
+				} else {
+					cost++
+				}
+			}
+			if bestCost < 0 || cost < bestCost || (cost == bestCost && best != preferred && (label == preferred || used[label] < used[best])) {
+				best, bestCost = label, cost
+			}
+		}
+		seat.Variant = best
+		assigned[[2]int{seat.Row, seat.Column}] = best
+		used[best]++
+	}
+}
+
+// countPaperVariants returns how many occupied seats got each variant, or nil when
+// the seats carry none.
+func countPaperVariants(seats []Seat) map[string]int {
+	var counts map[string]int
+	for _, seat := range seats {
+		if seat.IsEmpty || seat.Variant == "" {
+			continue
+		}
+		if counts == nil {
+			counts = make(map[string]int)
+		}
+		counts[seat.Variant]++
+	}
+	return counts
+}
+
+// Why: Alternating question-paper sets deters copying between neighbours, and per-room counts tell the exam cell how many copies of each set to send.
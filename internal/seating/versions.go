@@ -0,0 +1,186 @@
+package seating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of change reported by a plan diff.
+const (
+	ChangeMovedSeat = "moved_seat" // Same room, different seat
+	ChangeMovedRoom = "moved_room" // Different room
+	ChangeAdded     = "added"      // Seated only in the newer plan
+	ChangeRemoved   = "removed"    // Seated only in the older plan
+)
+
+// SeatPosition locates a student within a plan.
+type SeatPosition struct {
+	RoomID   primitive.ObjectID `json:"room_id"`
+	RoomName string             `json:"room_name"`
+	Row      int                `json:"row"`
+	Column   int                `json:"column"`
+	Variant  string             `json:"variant,omitempty"` // Paper variant handed out at the seat
+}
+
+// StudentMove describes how one student's seat differs between two plans.
+type StudentMove struct {
+	StudentID string        `json:"student_id"`
+	Change    string        `json:"change"`         // One of the Change* constants
+	From      *SeatPosition `json:"from,omitempty"` // Seat in the plan compared against
+	To        *SeatPosition `json:"to,omitempty"`   // Seat in the plan being viewed
+}
+
+// PlanDiff lists the students whose seat changed between two plans of an exam.
+type PlanDiff struct {
+	PlanID      primitive.ObjectID `json:"plan_id"`
+	AgainstID   primitive.ObjectID `json:"against_id"`
+	Version     int                `json:"version"`
+	AgainstVer  int                `json:"against_version"`
+	Unchanged   int                `json:"unchanged"`
+	Changes     []StudentMove      `json:"changes"`
+	ChangeCount map[string]int     `json:"change_count"` // Number of changes per kind
+}
+
+// checkNotSuperseded rejects changes to plans that a newer version has replaced.
+func checkNotSuperseded(plan *SeatingPlan) error {
+	if plan.SupersededBy != nil {
+		return fmt.Errorf("%w: plan was superseded by %s", ErrPlanNotEditable, plan.SupersededBy.Hex())
+	}
+	return nil
+}
+
+// saveNewVersion numbers a freshly built plan as the exam's next version and stores it.
+// When previous is given, the new plan is linked to it and previous is marked superseded.
+func (s *SeatingService) saveNewVersion(ctx context.Context, plan *SeatingPlan, previous *SeatingPlan) error {
+	version, err := s.repo.NextSeatingPlanVersion(ctx, plan.ExamID)
+	if err != nil {
+		return err
+	}
+	plan.Version = version
+	if previous != nil {
+		plan.PreviousPlanID = &previous.ID
+	}
+	if err := s.repo.CreateSeatingPlan(ctx, plan); err != nil {
+		return err
+	}
+	if previous != nil {
+		return s.repo.MarkSeatingPlanSuperseded(ctx, previous.ID, plan.ID, time.Now())
+	}
+	return nil
+}
+
+// latestPlanVersion returns the newest plan of an exam that no other version has
+// superseded, or nil when the exam has none yet. Candidates are not versions and are skipped.
+func (s *SeatingService) latestPlanVersion(ctx context.Context, examID primitive.ObjectID) (*SeatingPlan, error) {
+	plans, err := s.repo.FindSeatingPlansByExam(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	var latest *SeatingPlan
+	for _, plan := range plans {
+		if plan.CandidateGroup != nil || plan.SupersededBy != nil {
+			continue
+		}
+		if latest == nil || plan.Version > latest.Version {
+			latest = plan
+		}
+	}
+	return latest, nil
+}
+
+// GetExamPlanVersions returns the versioned plans of an exam, oldest first.
+// Undecided candidates are left out.
+func (s *SeatingService) GetExamPlanVersions(ctx context.Context, examID primitive.ObjectID) ([]*SeatingPlan, error) {
+	plans, err := s.repo.FindSeatingPlansByExam(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]*SeatingPlan, 0, len(plans))
+	for _, plan := range plans {
+		if plan.CandidateGroup == nil {
+			versions = append(versions, plan)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Version != versions[j].Version {
+			return versions[i].Version < versions[j].Version
+		}
+		return versions[i].CreatedAt.Before(versions[j].CreatedAt)
+	})
+	return versions, nil
+}
+
+// seatPositions maps every seated student of a plan to their seat.
+func seatPositions(plan *SeatingPlan) map[string]SeatPosition {
+	positions := make(map[string]SeatPosition)
+	for _, room := range plan.Rooms {
+		for _, seat := range room.Seats {
+			if !seat.IsEmpty && seat.StudentID != "" {
+				positions[seat.StudentID] = SeatPosition{RoomID: room.RoomID, RoomName: room.Name, Row: seat.Row, Column: seat.Column, Variant: seat.Variant}
+			}
+		}
+	}
+	return positions
+}
+
+// diffPlans compares plan against an older plan of the same exam.
+func diffPlans(plan, against *SeatingPlan) *PlanDiff {
+	diff := &PlanDiff{
+		PlanID:      plan.ID,
+		AgainstID:   against.ID,
+		Version:     plan.Version,
+		AgainstVer:  against.Version,
+		Changes:     []StudentMove{},
+		ChangeCount: map[string]int{},
+	}
+	now, before := seatPositions(plan), seatPositions(against)
+	for id, to := range now {
+		from, ok := before[id]
+		switch {
+		case !ok:
+			diff.Changes = append(diff.Changes, StudentMove{StudentID: id, Change: ChangeAdded, To: &to})
+		case from.RoomID != to.RoomID:
+			diff.Changes = append(diff.Changes, StudentMove{StudentID: id, Change: ChangeMovedRoom, From: &from, To: &to})
+		case from.Row != to.Row || from.Column != to.Column:
+			diff.Changes = append(diff.Changes, StudentMove{StudentID: id, Change: ChangeMovedSeat, From: &from, To: &to})
+		default:
+			diff.Unchanged++
+		}
+	}
+	for id, from := range before {
+		if _, ok := now[id]; !ok {
+			diff.Changes = append(diff.Changes, StudentMove{StudentID: id, Change: ChangeRemoved, From: &from})
+		}
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool { return diff.Changes[i].StudentID < diff.Changes[j].StudentID })
+	for _, change := range diff.Changes {
+		diff.ChangeCount[change.Change]++
+	}
+	return diff
+}
+
+// DiffSeatingPlans lists the students whose seat or room differs between two plans of one exam.
+func (s *SeatingService) DiffSeatingPlans(ctx context.Context, planID, againstID primitive.ObjectID) (*PlanDiff, error) {
+	plan, err := s.repo.FindSeatingPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	against, err := s.repo.FindSeatingPlanByID(ctx, againstID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil || against == nil {
+		return nil, errors.New("seating plan not found")
+	}
+	if plan.ExamID != against.ExamID {
+		return nil, errors.New("plans belong to different exams")
+	}
+	return diffPlans(plan, against), nil
+}
+
+// Why: Linking regenerated plans to the version they replace, and diffing them, lets staff notify only the students whose seat actually changed.
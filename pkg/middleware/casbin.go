@@ -35,7 +35,7 @@ func getCasbinModel() string {
 	e = some(where (p.eft == allow))
 
 	[matchers]
-	m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act`
+	m = g(r.sub, p.sub) && (keyMatch(r.obj, p.obj) || keyMatch2(r.obj, p.obj)) && r.act == p.act`
 	log.Println("[DEBUG] Casbin model string loaded:")
 	log.Println(modelStr)
 	if len(modelStr) < 50 || !containsAllSections(modelStr) {
@@ -85,6 +85,7 @@ func InitCasbinEnforcer() (*casbin.Enforcer, error) {
 			log.Fatalf("[FATAL] Error creating Casbin enforcer: %v", err)
 		}
 		enforcer.AddFunction("keyMatch", util.KeyMatchFunc)
+		enforcer.AddFunction("keyMatch2", util.KeyMatch2Func)
 		policies, _ := enforcer.GetPolicy()
 		log.Printf("Casbin enforcer created. Policy count: %d", len(policies))
 	})
@@ -29,6 +29,7 @@ var EchoModules = fx.Module("echo",
 	fx.Provide(notification.NewNotificationHandler),
 	fx.Provide(notification.NewNotificationScheduler),
 	fx.Provide(seating.NewSeatingRepository),
+	fx.Provide(seating.NewAlgorithmRegistry),
 	fx.Provide(seating.NewSeatingService),
 	fx.Provide(seating.NewSeatingHandler),
 	fx.Invoke(RegisterRoutes),
@@ -87,6 +88,7 @@ func RegisterRoutes(e *echo.Echo, authHandler *auth.AuthHandler, notificationHan
 	// Seating routes
 	seating := protected.Group("/seating")
 	seating.POST("/generate", seatingHandler.GenerateSeatingPlan)   // Admin only
+	seating.GET("/algorithms", seatingHandler.ListAlgorithms)       // All authenticated users
 	seating.GET("/plans/:id", seatingHandler.GetSeatingPlan)        // All authenticated users
 	seating.POST("/exams", seatingHandler.CreateExam)               // Admin only
 	seating.DELETE("/exams/:id", seatingHandler.DeleteExam)         // Admin only
@@ -97,6 +99,7 @@ func RegisterRoutes(e *echo.Echo, authHandler *auth.AuthHandler, notificationHan
 
 	// New student list management routes
 	seating.POST("/student-lists", seatingHandler.UploadStudentList)                               // Staff only
+	seating.POST("/student-lists/import", seatingHandler.ImportStudentList)                        // Staff only
 	seating.GET("/student-lists", seatingHandler.GetAllStudentLists)                               // All authenticated users
 	seating.GET("/student-lists/faculty", seatingHandler.GetStudentListsByFaculty)                 // Admin only
 	seating.DELETE("/student-lists/:id", seatingHandler.DeleteStudentList)                         // Admin only
@@ -105,20 +108,61 @@ func RegisterRoutes(e *echo.Echo, authHandler *auth.AuthHandler, notificationHan
 	seating.PUT("/student-lists/:id/students/:studentId", seatingHandler.UpdateStudentInList)      // Admin only
 	seating.DELETE("/student-lists/:id/students/:studentId", seatingHandler.RemoveStudentFromList) // Admin only
 	seating.GET("/invigilators", seatingHandler.GetAllInvigilators)                                // All authenticated users
+	seating.POST("/my-unavailability", seatingHandler.DeclareUnavailability)                       // Admin and staff
+	seating.GET("/my-unavailability", seatingHandler.GetMyUnavailability)                          // Admin and staff
+	seating.DELETE("/my-unavailability/:id", seatingHandler.DeleteMyUnavailability)                // Admin and staff
+	seating.GET("/unavailability", seatingHandler.ListUnavailability)                              // Admin only
+	seating.GET("/my-duties", seatingHandler.GetMyDuties)                                          // Admin and staff
+	seating.GET("/duty-roster", seatingHandler.GetDutyRoster)                                      // Admin only
+	seating.POST("/my-duty-swaps", seatingHandler.ProposeDutySwap)                                 // Admin and staff
+	seating.GET("/my-duty-swaps", seatingHandler.GetMyDutySwaps)                                   // Admin and staff
+	seating.POST("/my-duty-swaps/:id/:action", seatingHandler.RespondToDutySwap)                   // Admin and staff; accept, decline or cancel
+	seating.GET("/duty-swaps", seatingHandler.ListDutySwaps)                                       // Admin only
+	seating.POST("/duty-swaps/:id/:action", seatingHandler.DecideDutySwap)                         // Admin only; approve or reject
+	seating.PUT("/exam-rooms/:id/invigilators/:invigilatorId", seatingHandler.SetInvigilatorRole)  // Admin only
 
 	// New exam room management routes
-	seating.POST("/exam-rooms", seatingHandler.AddRoomToExam)                      // Admin only
-	seating.POST("/exam-rooms/invigilators", seatingHandler.AddInvigilatorToRoom)  // Admin only
-	seating.POST("/exam-rooms/clear/:examId", seatingHandler.ClearRoomAssignments) // Admin only
-	seating.GET("/exams/:examId/rooms", seatingHandler.GetExamRooms)               // All authenticated users
-	seating.DELETE("/rooms/:id", seatingHandler.DeleteRoom)                        // Admin only
-	seating.PUT("/rooms/:id", seatingHandler.UpdateRoom)                           // Admin only
+	seating.POST("/exam-rooms", seatingHandler.AddRoomToExam)                            // Admin only
+	seating.POST("/exam-rooms/invigilators", seatingHandler.AddInvigilatorToRoom)        // Admin only
+	seating.POST("/exam-rooms/invigilators/auto", seatingHandler.AutoAssignInvigilators) // Admin only
+	seating.GET("/exams/:examId/staffing", seatingHandler.GetExamStaffing)               // Admin and staff
+	seating.POST("/exam-rooms/clear/:examId", seatingHandler.ClearRoomAssignments)       // Admin only
+	seating.GET("/exams/:examId/rooms", seatingHandler.GetExamRooms)                     // All authenticated users
+	seating.GET("/clashes", seatingHandler.GetClashReport)                               // Admin and staff
+	seating.POST("/timetable", seatingHandler.GenerateTimetable)                         // Admin only
+	seating.POST("/exams/:examId/rooms/confirm", seatingHandler.ConfirmExamRooms)        // Admin only
+	seating.POST("/exams/:examId/rooms/auto", seatingHandler.AutoAllocateRooms)          // Admin only
+	seating.DELETE("/rooms/:id", seatingHandler.DeleteRoom)                              // Admin only
+	seating.PUT("/rooms/:id", seatingHandler.UpdateRoom)                                 // Admin only
+	seating.GET("/rooms/:id/layout", seatingHandler.GetRoomLayout)                       // Admin and staff
+	seating.PUT("/rooms/:id/layout", seatingHandler.SetRoomLayout)                       // Admin and staff
+	seating.PUT("/rooms/:id/layout/cells", seatingHandler.UpdateRoomLayoutCells)         // Admin and staff
+	seating.DELETE("/rooms/:id/layout", seatingHandler.ResetRoomLayout)                  // Admin and staff
+	seating.GET("/rooms/:id/calendar", seatingHandler.GetRoomCalendar)                   // Admin and staff
+	seating.POST("/rooms/:id/blocks", seatingHandler.BlockRoom)                          // Admin and staff
+	seating.DELETE("/rooms/:id/blocks/:blockId", seatingHandler.UnblockRoom)             // Admin and staff
 
 	// New GET endpoints for lists
 	seating.GET("/exams", seatingHandler.GetAllExams)
 	seating.GET("/rooms", seatingHandler.GetAllRooms)
 	seating.GET("/students", seatingHandler.GetAllStudents)
-	seating.GET("/plans", seatingHandler.GetAllSeatingPlans)       // All authenticated users
-	seating.GET("/my-plans", seatingHandler.GetMySeatingPlans)     // Students only
-	seating.DELETE("/plans/:id", seatingHandler.DeleteSeatingPlan) // Admin only
+	seating.GET("/plans", seatingHandler.GetAllSeatingPlans)                          // All authenticated users
+	seating.GET("/my-plans", seatingHandler.GetMySeatingPlans)                        // Students only
+	seating.DELETE("/plans/:id", seatingHandler.DeleteSeatingPlan)                    // Admin only
+	seating.POST("/plans/:id/regenerate", seatingHandler.RegenerateSeatingPlan)       // Admin only
+	seating.GET("/plans/:id/report", seatingHandler.GetSeatingPlanReport)             // Admin and staff
+	seating.GET("/plans/:id/diff", seatingHandler.DiffSeatingPlan)                    // Admin and staff
+	seating.GET("/plans/:id/drift", seatingHandler.GetPlanDrift)                      // Admin and staff
+	seating.POST("/plans/:id/reseat", seatingHandler.ReseatSeatingPlan)               // Admin only
+	seating.GET("/exams/:examId/plans", seatingHandler.GetExamPlanVersions)           // Admin and staff
+	seating.POST("/plans/:id/promote", seatingHandler.PromoteCandidatePlan)           // Admin only
+	seating.POST("/plans/:id/seats/swap", seatingHandler.SwapSeats)                   // Admin only
+	seating.POST("/plans/:id/seats/move", seatingHandler.MoveStudent)                 // Admin only
+	seating.POST("/plans/:id/seats/lock", seatingHandler.LockSeats)                   // Admin only
+	seating.POST("/plans/:id/seats/unlock", seatingHandler.UnlockSeats)               // Admin only
+	seating.POST("/plans/:id/status/:status", seatingHandler.UpdateSeatingPlanStatus) // Role per target state, see rbac_policy.csv
+	seating.POST("/generate/candidates", seatingHandler.GenerateCandidatePlans)       // Admin only
+	seating.GET("/candidates/:group", seatingHandler.GetCandidatePlans)               // Admin only
+	seating.POST("/generate/session", seatingHandler.GenerateSessionPlan)             // Admin only
+	seating.GET("/plans/:id/exams/:examId", seatingHandler.GetSessionExamView)        // Admin and staff
 }